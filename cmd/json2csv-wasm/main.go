@@ -0,0 +1,111 @@
+//go:build js && wasm
+// +build js,wasm
+
+// Command json2csv-wasm exposes the converter to JavaScript.
+//
+// It registers a global function:
+//
+//	json2csv(input, options) -> {csv: string} | {error: string}
+//
+// input is a JSON text (string or Uint8Array).
+// options is an optional object with "headerStyle" (jsonpointer, slash, dot,
+// dot-bracket), "path" (JSON Pointer of the content) and "transpose" (bool).
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"syscall/js"
+
+	"github.com/yukithm/json2csv/v2"
+)
+
+var headerStyleTable = map[string]json2csv.KeyStyle{
+	"jsonpointer": json2csv.JSONPointerStyle,
+	"slash":       json2csv.SlashStyle,
+	"dot":         json2csv.DotNotationStyle,
+	"dot-bracket": json2csv.DotBracketStyle,
+}
+
+func main() {
+	js.Global().Set("json2csv", js.FuncOf(convertFunc))
+
+	// Keep running to serve calls from JavaScript.
+	select {}
+}
+
+func convertFunc(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return errorResult(fmt.Errorf("json2csv: input is required"))
+	}
+
+	input, err := inputBytes(args[0])
+	if err != nil {
+		return errorResult(err)
+	}
+
+	var opts json2csv.Options
+	if len(args) > 1 {
+		if opts, err = parseOptions(args[1]); err != nil {
+			return errorResult(err)
+		}
+	}
+
+	csv, err := convert(input, opts)
+	if err != nil {
+		return errorResult(err)
+	}
+	return map[string]interface{}{"csv": csv}
+}
+
+func inputBytes(v js.Value) ([]byte, error) {
+	switch {
+	case v.Type() == js.TypeString:
+		return []byte(v.String()), nil
+	case v.InstanceOf(js.Global().Get("Uint8Array")):
+		b := make([]byte, v.Get("length").Int())
+		js.CopyBytesToGo(b, v)
+		return b, nil
+	default:
+		return nil, fmt.Errorf("json2csv: input must be a string or Uint8Array")
+	}
+}
+
+func parseOptions(v js.Value) (json2csv.Options, error) {
+	var opts json2csv.Options
+	if v.IsUndefined() || v.IsNull() {
+		return opts, nil
+	}
+
+	if s := v.Get("headerStyle"); s.Type() == js.TypeString {
+		style, ok := headerStyleTable[s.String()]
+		if !ok {
+			return opts, fmt.Errorf("Invalid headerStyle value %q", s.String())
+		}
+		opts.Writer.HeaderStyle = style
+	}
+	if p := v.Get("path"); p.Type() == js.TypeString {
+		opts.Path = p.String()
+	}
+	if t := v.Get("transpose"); t.Type() == js.TypeBoolean {
+		opts.Writer.Transpose = t.Bool()
+	}
+	return opts, nil
+}
+
+func convert(input []byte, opts json2csv.Options) (string, error) {
+	conv, err := json2csv.NewConverter(opts)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := conv.ConvertReader(context.Background(), &b, bytes.NewReader(input)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func errorResult(err error) interface{} {
+	return map[string]interface{}{"error": err.Error()}
+}
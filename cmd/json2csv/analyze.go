@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/urfave/cli"
+	"github.com/yukithm/json2csv/v2"
+)
+
+// analysis is the output of --analyze for an input.
+type analysis struct {
+	Source  string                 `json:"source"`
+	Rows    int                    `json:"rows"`
+	Columns []json2csv.ColumnStats `json:"columns"`
+}
+
+// analyzeAction prints the column statistics of the inputs as JSON instead
+// of converting them (--analyze).
+func analyzeAction(inputs []string, c *cli.Context) error {
+	out := &countingWriter{w: os.Stdout}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	for _, input := range inputs {
+		results, err := readResults(input, c)
+		if err != nil {
+			return fmt.Errorf("%s: %s", sourceName(input), err)
+		}
+		columns, err := newCSVWriter(ioutil.Discard, c).ColumnStats(results)
+		if err != nil {
+			return fmt.Errorf("%s: %s", sourceName(input), err)
+		}
+
+		n := out.n
+		if err := enc.Encode(analysis{sourceName(input), len(results), columns}); err != nil {
+			return err
+		}
+		stats.Add(sourceName(input), "", len(results), len(columns), out.n-n)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/urfave/cli"
+)
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// hasOutputAssertions reports whether any of the output assertions is set.
+func hasOutputAssertions(c *cli.Context) bool {
+	for _, name := range []string{"expect-rows", "min-rows", "max-rows", "min-bytes", "max-bytes"} {
+		if c.IsSet(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOutput checks the number of rows and bytes of the output by
+// --expect-rows, --min-rows, --max-rows, --min-bytes and --max-bytes.
+func checkOutput(rows int, size int64, c *cli.Context) error {
+	if c.IsSet("expect-rows") && rows != c.Int("expect-rows") {
+		return fmt.Errorf("Unexpected row count %d (expected %d)", rows, c.Int("expect-rows"))
+	}
+	if c.IsSet("min-rows") && rows < c.Int("min-rows") {
+		return fmt.Errorf("Unexpected row count %d (expected at least %d)", rows, c.Int("min-rows"))
+	}
+	if c.IsSet("max-rows") && rows > c.Int("max-rows") {
+		return fmt.Errorf("Unexpected row count %d (expected at most %d)", rows, c.Int("max-rows"))
+	}
+
+	// already validated
+	if c.IsSet("min-bytes") {
+		if min, _ := parseSize(c.String("min-bytes")); size < min {
+			return fmt.Errorf("Unexpected output size %d bytes (expected at least %d bytes)", size, min)
+		}
+	}
+	if c.IsSet("max-bytes") {
+		if max, _ := parseSize(c.String("max-bytes")); size > max {
+			return fmt.Errorf("Unexpected output size %d bytes (expected at most %d bytes)", size, max)
+		}
+	}
+	return nil
+}
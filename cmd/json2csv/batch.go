@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/urfave/cli"
+	"github.com/yukithm/json2csv/v2"
+)
+
+// outputNamer builds output file names from a template.
+type outputNamer struct {
+	tmpl *template.Template
+	now  time.Time
+}
+
+// outputNameData is the data passed to the output name template.
+type outputNameData struct {
+	Stem      string // "orders" for "data/orders.json"
+	Name      string // "orders.json"
+	Ext       string // ".json"
+	Index     int    // position in the inputs, starts at 1
+	Date      string // "2006-01-02"
+	Time      string // "150405"
+	Partition string // value of --partition-by
+}
+
+func newOutputNamer(text string, now time.Time) (*outputNamer, error) {
+	tmpl, err := template.New("output-name").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &outputNamer{tmpl: tmpl, now: now}, nil
+}
+
+// Name returns the output file name for the input.
+func (n *outputNamer) Name(input string, index int) (string, error) {
+	return n.PartitionName(input, index, "")
+}
+
+// PartitionName returns the output file name for the partition of the input.
+func (n *outputNamer) PartitionName(input string, index int, partition string) (string, error) {
+	name := filepath.Base(input)
+	ext := filepath.Ext(name)
+	data := outputNameData{
+		Stem:      strings.TrimSuffix(name, ext),
+		Name:      name,
+		Ext:       ext,
+		Index:     index,
+		Date:      n.now.Format("2006-01-02"),
+		Time:      n.now.Format("150405"),
+		Partition: partition,
+	}
+
+	var b bytes.Buffer
+	if err := n.tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	if b.Len() == 0 {
+		return "", fmt.Errorf("Empty output name for %q", input)
+	}
+	return b.String(), nil
+}
+
+// convertEach converts each input into its own CSV file in --output-dir.
+func convertEach(inputs []string, c *cli.Context) error {
+	namer, err := newOutputNamer(c.String("output-name"), time.Now())
+	if err != nil {
+		return err
+	}
+
+	outputDir := c.String("output-dir")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return err
+	}
+
+	outputs := make(map[string]string, len(inputs))
+	for i, input := range inputs {
+		if interrupted() {
+			return errInterrupted
+		}
+		if input == "-" {
+			return fmt.Errorf("STDIN can't be used with --output-dir")
+		}
+
+		name, err := namer.Name(input, i+1)
+		if err != nil {
+			return err
+		}
+		output := filepath.Join(outputDir, name)
+		if prev, ok := outputs[output]; ok {
+			return fmt.Errorf("%q and %q have the same output %q", prev, input, output)
+		}
+		outputs[output] = input
+
+		if err := convertCached(input, output, c); err != nil {
+			return fmt.Errorf("%s: %s", input, err)
+		}
+	}
+	return nil
+}
+
+// combinedInputName is the input file name of the output template with
+// --combine.
+const combinedInputName = "combined"
+
+// convertCombined converts all inputs into a single CSV.
+func convertCombined(inputs []string, output string, c *cli.Context) error {
+	var results []json2csv.KeyValue
+	sources := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		sources = append(sources, sourceName(input))
+		r, err := readResults(input, c)
+		if err != nil {
+			return fmt.Errorf("%s: %s", input, err)
+		}
+		results = append(results, r...)
+	}
+	return writeResults(results, strings.Join(sources, ", "), combinedInputName, output, c)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/urfave/cli"
+	"github.com/yukithm/json2csv/v2"
+)
+
+// checksumCache records checksums of converted inputs, so that unchanged
+// inputs can be skipped in the next run with the same options.
+type checksumCache struct {
+	Entries map[string]cacheEntry `json:"entries"`
+
+	filename string
+	options  string // conversionHash of the run
+}
+
+type cacheEntry struct {
+	Checksum string `json:"checksum"`
+	Output   string `json:"output"`
+
+	// conversionHash of the run which converted the input
+	Options string `json:"options,omitempty"`
+
+	// size and modification time (UnixNano) of the input, if known
+	Size    int64 `json:"size,omitempty"`
+	ModTime int64 `json:"mod_time,omitempty"`
+
+	// the conversion failed
+	Failed bool `json:"failed,omitempty"`
+}
+
+// loadChecksumCache loads the cache file. A missing file is an empty cache.
+// The entries match only if they were recorded with the same options.
+func loadChecksumCache(filename string, options string) (*checksumCache, error) {
+	cache := &checksumCache{
+		filename: filename,
+		options:  options,
+		Entries:  map[string]cacheEntry{},
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return cache, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, cache); err != nil {
+		return nil, err
+	}
+	if cache.Entries == nil {
+		cache.Entries = map[string]cacheEntry{}
+	}
+	return cache, nil
+}
+
+// Unchanged returns true if the input has the same checksum and options as
+// the last run and its output still exists.
+func (c *checksumCache) Unchanged(input, output, checksum string) bool {
+	entry, ok := c.Entries[cacheKey(input)]
+	if !ok || entry.Failed || entry.Checksum != checksum || entry.Output != output || entry.Options != c.options {
+		return false
+	}
+	if output == "" {
+		// The rows have been appended to a shared output (--rotate).
+		return true
+	}
+	if _, err := os.Stat(json2csv.LocalPath(output)); err != nil {
+		return false
+	}
+	return true
+}
+
+// Stat returns the entry of the input if the file has the same size and
+// modification time as recorded with the same options, so that the checksum
+// needn't be computed.
+func (c *checksumCache) Stat(input string, info os.FileInfo) (cacheEntry, bool) {
+	entry, ok := c.Entries[cacheKey(input)]
+	if !ok || entry.ModTime == 0 || entry.Options != c.options || entry.Size != info.Size() || entry.ModTime != info.ModTime().UnixNano() {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// Update records the checksum of the input. The info of the input may be nil.
+func (c *checksumCache) Update(input, output, checksum string, info os.FileInfo) {
+	entry := cacheEntry{
+		Checksum: checksum,
+		Output:   output,
+		Options:  c.options,
+	}
+	if info != nil {
+		entry.Size = info.Size()
+		entry.ModTime = info.ModTime().UnixNano()
+	}
+	c.Entries[cacheKey(input)] = entry
+}
+
+// Fail records the input which failed to convert.
+func (c *checksumCache) Fail(input, checksum string, info os.FileInfo) {
+	c.Update(input, "", checksum, info)
+	entry := c.Entries[cacheKey(input)]
+	entry.Failed = true
+	c.Entries[cacheKey(input)] = entry
+}
+
+// Save writes the cache file atomically.
+func (c *checksumCache) Save() error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(c.filename), ".json2csv-cache-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.filename)
+}
+
+// outputIndependentFlags are the flags which don't change the output of a
+// conversion, or are already part of the cache entries.
+var outputIndependentFlags = map[string]bool{
+	"cache":                true,
+	"ledger":               true,
+	"watch":                true,
+	"interval":             true,
+	"concurrency":          true,
+	"timeout":              true,
+	"on-interrupt":         true,
+	"output":               true,
+	"output-dir":           true,
+	"output-name":          true,
+	"socket":               true,
+	"parallel":             true,
+	"mapping-file":         true,
+	"update-golden-schema": true,
+	"quiet":                true,
+	"verbose":              true,
+	"vv":                   true,
+}
+
+// conversionHash returns the hash of the version and the flags which change
+// the output, including the contents of --header-translations, so that an
+// input converted with other options isn't skipped.
+func conversionHash(c *cli.Context) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "version=%s\n", version)
+	for _, name := range c.GlobalFlagNames() {
+		if !outputIndependentFlags[name] {
+			fmt.Fprintf(h, "%s=%v\n", name, c.Generic(name))
+		}
+	}
+	if filename := c.String("header-translations"); filename != "" {
+		if b, err := ioutil.ReadFile(filename); err == nil {
+			h.Write(b)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func cacheKey(input string) string {
+	input = json2csv.LocalPath(input)
+	if abs, err := filepath.Abs(input); err == nil {
+		return abs
+	}
+	return input
+}
+
+// fileChecksum returns the SHA-256 checksum of the file.
+func fileChecksum(filename string) (string, error) {
+	f, err := os.Open(json2csv.LocalPath(filename))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestConversionHash(t *testing.T) {
+	hash := func(args ...string) string {
+		app := cli.NewApp()
+		app.Flags = []cli.Flag{
+			cli.StringFlag{Name: "header-style", Value: "jsonpointer"},
+			cli.StringSliceFlag{Name: "rule"},
+			cli.StringFlag{Name: "cache"},
+		}
+		set := flag.NewFlagSet("test", flag.ContinueOnError)
+		for _, f := range app.Flags {
+			f.Apply(set)
+		}
+		if err := set.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		return conversionHash(cli.NewContext(app, set, nil))
+	}
+
+	base := hash()
+	testCases := []struct {
+		args []string
+		same bool
+	}{
+		{[]string{"--header-style=jsonpointer"}, true},
+		{[]string{"--cache=other.json"}, true},
+		{[]string{"--header-style=dot"}, false},
+		{[]string{"--rule=if /a == 1 then /b := 2"}, false},
+	}
+	for caseIndex, testCase := range testCases {
+		if got := hash(testCase.args...); (got == base) != testCase.same {
+			t.Errorf("%d: Expected same=%v for %v", caseIndex, testCase.same, testCase.args)
+		}
+	}
+
+	cache := &checksumCache{options: base, Entries: map[string]cacheEntry{}}
+	cache.Update("input.json", "", "sum", nil)
+	if !cache.Unchanged("input.json", "", "sum") {
+		t.Errorf("Expected unchanged with the same options")
+	}
+	cache.options = hash("--header-style=dot")
+	if cache.Unchanged("input.json", "", "sum") {
+		t.Errorf("Expected changed with other options")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"text/template"
+	"time"
+)
+
+// commentData is the data passed to the comment templates.
+type commentData struct {
+	Source string // input file names, or "stdin"
+	Rows   int    // number of rows (0 in --stream mode)
+	Date   string // "2006-01-02"
+	Time   string // "15:04:05"
+}
+
+func parseCommentTemplates(texts []string) ([]*template.Template, error) {
+	tmpls := make([]*template.Template, 0, len(texts))
+	for _, text := range texts {
+		tmpl, err := template.New("comment").Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		tmpls = append(tmpls, tmpl)
+	}
+	return tmpls, nil
+}
+
+// renderComments renders the comment templates.
+func renderComments(texts []string, source string, rows int, now time.Time) ([]string, error) {
+	tmpls, err := parseCommentTemplates(texts)
+	if err != nil {
+		return nil, err
+	}
+
+	data := commentData{
+		Source: source,
+		Rows:   rows,
+		Date:   now.Format("2006-01-02"),
+		Time:   now.Format("15:04:05"),
+	}
+	comments := make([]string, 0, len(tmpls))
+	for _, tmpl := range tmpls {
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, data); err != nil {
+			return nil, err
+		}
+		comments = append(comments, b.String())
+	}
+	return comments, nil
+}
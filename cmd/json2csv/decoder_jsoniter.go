@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/yukithm/json2csv/v2"
+)
+
+// jsoniterConfig trades strict compatibility with encoding/json for speed.
+var jsoniterConfig = jsoniter.Config{UseNumber: true}.Froze()
+
+// mappedDecoders decode the first JSON value from memory without copying the
+// input, keyed by the decoder backend. They must give the same result as the
+// Decoder of the backend reading the same bytes.
+var mappedDecoders = map[string]func(data []byte) (interface{}, error){
+	"jsoniter": decodeJsoniterBytes,
+}
+
+// decodeJsoniterBytes decodes like jsoniterDecoder.Decode, which ignores the
+// data after the first value.
+func decodeJsoniterBytes(data []byte) (interface{}, error) {
+	if len(data) == 0 {
+		return nil, io.EOF
+	}
+	iter := jsoniterConfig.BorrowIterator(data)
+	defer jsoniterConfig.ReturnIterator(iter)
+	var v interface{}
+	iter.ReadVal(&v)
+	if iter.Error != nil && iter.Error != io.EOF {
+		return nil, iter.Error
+	}
+	return v, nil
+}
+
+func init() {
+	json2csv.RegisterDecoder("jsoniter", newJsoniterDecoder)
+}
+
+type jsoniterDecoder struct {
+	*jsoniter.Decoder
+}
+
+func newJsoniterDecoder(r io.Reader) json2csv.Decoder {
+	return &jsoniterDecoder{jsoniterConfig.NewDecoder(r)}
+}
+
+// Decode returns io.EOF if only whitespace is left, like the Decoder of
+// encoding/json.
+func (d *jsoniterDecoder) Decode() (interface{}, error) {
+	// More is also false at ']' and '}', which Decode reports as invalid.
+	// The whitespace before the end of the input is left in the buffer.
+	if !d.Decoder.More() {
+		rest, _ := ioutil.ReadAll(d.Decoder.Buffered())
+		if len(bytes.Trim(rest, " \t\r\n")) == 0 {
+			return nil, io.EOF
+		}
+	}
+	var v interface{}
+	if err := d.Decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/urfave/cli"
+	"github.com/yukithm/json2csv/v2"
+	"github.com/yukithm/json2csv/v2/jsonpointer"
+)
+
+// estimate is the projection of a conversion from a sample of the input.
+type estimate struct {
+	inputSize  int64 // -1 if unknown
+	sampleSize int64 // bytes of the input read for the sample
+	rows       int   // rows in the sample
+	columns    int   // columns in the sample
+	outputSize int64 // bytes of the output of the sample
+	complete   bool  // the sample is the whole input
+}
+
+// ratio returns the ratio of the whole input to the sample, or 0 if unknown.
+func (e *estimate) ratio() float64 {
+	if e.complete {
+		return 1
+	}
+	if e.inputSize < 0 || e.sampleSize == 0 {
+		return 0
+	}
+	return float64(e.inputSize) / float64(e.sampleSize)
+}
+
+func (e *estimate) String() string {
+	if e.complete {
+		return fmt.Sprintf("%d rows, %d columns, %s of output", e.rows, e.columns, formatSize(e.outputSize))
+	}
+
+	ratio := e.ratio()
+	if ratio == 0 {
+		return fmt.Sprintf("%d+ rows, %d+ columns, %s+ of output (sampled %d rows from %s; input size unknown)",
+			e.rows, e.columns, formatSize(e.outputSize), e.rows, formatSize(e.sampleSize))
+	}
+	return fmt.Sprintf("~%d rows, %d+ columns, ~%s of output (sampled %d rows from %s of %s)",
+		int64(float64(e.rows)*ratio), e.columns, formatSize(int64(float64(e.outputSize)*ratio)),
+		e.rows, formatSize(e.sampleSize), formatSize(e.inputSize))
+}
+
+// estimateAction prints the estimates of the conversions of the inputs
+// without writing any output (--estimate).
+func estimateAction(inputs []string, c *cli.Context) error {
+	for _, input := range inputs {
+		e, err := estimateInput(input, c)
+		if err != nil {
+			return fmt.Errorf("%s: %s", sourceName(input), err)
+		}
+		fmt.Printf("%s: %s\n", sourceName(input), e)
+	}
+	return nil
+}
+
+// estimateInput samples the first --estimate-sample rows of the input
+// ("-" means STDIN).
+func estimateInput(input string, c *cli.Context) (*estimate, error) {
+	var r io.Reader = os.Stdin
+	size := inputSize(os.Stdin)
+	if input != "-" {
+		f, err := openInput(input, c.Bool("mmap"))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+		size = -1
+		if file, ok := f.(*os.File); ok {
+			size = inputSize(file)
+		} else if m, ok := f.(*mappedReader); ok {
+			size = m.Size()
+		}
+	}
+
+	// already validated
+	decoder, _ := json2csv.LookupDecoder(c.String("decoder"))
+	results, read, complete, err := sampleJSON(r, c.Int("estimate-sample"), c.String("path"), rowOptions(c), decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := json2csv.NewCSVHeader(results)
+	if err != nil {
+		return nil, err
+	}
+	out := &countingWriter{w: ioutil.Discard}
+	if len(results) > 0 {
+		if err := printCSV(out, sourceName(input), results, nil, c); err != nil {
+			return nil, err
+		}
+	}
+	return &estimate{
+		inputSize:  size,
+		sampleSize: read,
+		rows:       len(results),
+		columns:    len(header),
+		outputSize: out.n,
+		complete:   complete,
+	}, nil
+}
+
+// inputSize returns the size of the regular file, or -1.
+func inputSize(f *os.File) int64 {
+	fi, err := f.Stat()
+	if err != nil || !fi.Mode().IsRegular() {
+		return -1
+	}
+	return fi.Size()
+}
+
+// sampleJSON reads at least n rows (unless the input is shorter) from an
+// array of objects or a sequence of JSON values by the decoder backend. It
+// returns the rows, the number of bytes read and whether the whole input has
+// been read.
+// With path, the whole input is read since the rows can't be located without
+// parsing it.
+func sampleJSON(r io.Reader, n int, path string, opts json2csv.Options, decoder json2csv.DecoderFactory) ([]json2csv.KeyValue, int64, bool, error) {
+	conv, err := json2csv.NewConverter(opts)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	ctx := context.Background()
+
+	if path != "" {
+		cr := &countingReader{r: r}
+		data, err := decoder(cr).Decode()
+		if err != nil {
+			return nil, 0, false, err
+		}
+		data, err = jsonpointer.Get(data, path)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		results, err := conv.Flatten(ctx, data)
+		return results, cr.n, true, err
+	}
+
+	br := bufio.NewReader(r)
+	first, err := peekNonSpace(br)
+	if err == io.EOF {
+		return nil, 0, true, nil
+	} else if err != nil {
+		return nil, 0, false, err
+	}
+
+	// encoding/json only splits the input into the values, which are
+	// decoded by the decoder backend.
+	dec := json.NewDecoder(br)
+	decode := func() (interface{}, error) {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		return decoder(bytes.NewReader(raw)).Decode()
+	}
+
+	var results []json2csv.KeyValue
+	if first == '[' {
+		// Read the elements of the top-level array one by one.
+		if _, err := dec.Token(); err != nil {
+			return nil, 0, false, err
+		}
+		for len(results) < n && dec.More() {
+			elem, err := decode()
+			if err != nil {
+				return nil, 0, false, err
+			}
+			obj, ok := elem.(map[string]interface{})
+			if !ok {
+				return nil, 0, false, fmt.Errorf("Can't estimate: the top-level array must consist of objects")
+			}
+			kv, err := conv.Flatten(ctx, obj)
+			if err != nil {
+				return nil, 0, false, err
+			}
+			results = append(results, kv...)
+		}
+		return results, dec.InputOffset(), !dec.More(), nil
+	}
+
+	for len(results) < n {
+		data, err := decode()
+		if err == io.EOF {
+			return results, dec.InputOffset(), true, nil
+		} else if err != nil {
+			return nil, 0, false, err
+		}
+		kv, err := conv.Flatten(ctx, data)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		results = append(results, kv...)
+	}
+	// More reports false at the end of the top-level sequence.
+	return results, dec.InputOffset(), !dec.More(), nil
+}
+
+// countingReader counts the bytes read.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// peekNonSpace skips the leading whitespace and returns the next byte
+// without consuming it.
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			br.ReadByte()
+		default:
+			return b[0], nil
+		}
+	}
+}
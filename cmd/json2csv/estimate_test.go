@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/yukithm/json2csv/v2"
+)
+
+func TestSampleJSON(t *testing.T) {
+	testCases := []struct {
+		in       string
+		n        int
+		path     string
+		rows     int
+		read     int64
+		complete bool
+	}{
+		{``, 2, "", 0, 0, true},
+		{`[{"a": 1}, {"a": 2}, {"a": 3}]`, 2, "", 2, 19, false},
+		{`[{"a": 1}, {"a": 2}]`, 2, "", 2, 19, true},
+		{"{\"a\": 1}\n{\"a\": 2}\n{\"a\": 3}\n", 2, "", 2, 17, false},
+		{"{\"a\": 1}\n{\"a\": 2}\n", 5, "", 2, 17, true},
+		{`{"items": [{"a": 1}, {"a": 2}]}`, 1, "/items", 2, 31, true},
+	}
+
+	std, _ := json2csv.LookupDecoder("std")
+	for caseIndex, testCase := range testCases {
+		results, read, complete, err := sampleJSON(strings.NewReader(testCase.in), testCase.n, testCase.path, json2csv.Options{}, std)
+		if err != nil {
+			t.Fatalf("%d: %s", caseIndex, err)
+		}
+		if len(results) != testCase.rows || read != testCase.read || complete != testCase.complete {
+			t.Errorf("%d: Expected %d rows, %d bytes, complete=%v, but %d rows, %d bytes, complete=%v",
+				caseIndex, testCase.rows, testCase.read, testCase.complete, len(results), read, complete)
+		}
+	}
+}
+
+type countingDecoder struct {
+	json2csv.Decoder
+	calls *int
+}
+
+func (d countingDecoder) Decode() (interface{}, error) {
+	*d.calls++
+	return d.Decoder.Decode()
+}
+
+func TestSampleJSONDecoder(t *testing.T) {
+	calls := 0
+	decoder := func(r io.Reader) json2csv.Decoder {
+		return countingDecoder{json2csv.NewStdDecoder(r), &calls}
+	}
+
+	results, _, _, err := sampleJSON(strings.NewReader(`[{"a": 1}, {"a": 2}]`), 10, "", json2csv.Options{}, decoder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || calls != 2 {
+		t.Errorf("Expected 2 rows decoded by the decoder, but %d rows by %d calls", len(results), calls)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	testCases := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{999, "999 B"},
+		{1000, "1.0 KB"},
+		{1500000, "1.5 MB"},
+		{2 * 1000 * 1000 * 1000 * 1000 * 1000, "2000.0 TB"},
+	}
+
+	for caseIndex, testCase := range testCases {
+		if got := formatSize(testCase.n); got != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, got)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli"
+	"github.com/yukithm/json2csv/v2"
+)
+
+// genCommand generates test fixtures of the pipelines consuming the CSV.
+var genCommand = cli.Command{
+	Name:      "gen",
+	Usage:     "generate synthetic JSON which has the columns of SCHEMA",
+	ArgsUsage: "SCHEMA",
+	Description: "SCHEMA is a file of JSON Pointers (one per line, e.g. written by --update-golden-schema)\n" +
+		"   or a CSV file whose header is JSON Pointers.",
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "rows, n",
+			Value: 10,
+			Usage: "number of objects",
+		},
+		cli.BoolFlag{
+			Name:  "ndjson",
+			Usage: "write one object per line instead of an array",
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "write JSON to `FILE` instead of STDOUT",
+		},
+	},
+	Action: genAction,
+}
+
+func genAction(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("gen requires a SCHEMA file")
+	}
+	if c.Int("rows") < 0 {
+		return fmt.Errorf("Invalid --rows value %d", c.Int("rows"))
+	}
+
+	schema, err := readGenSchema(c.Args()[0])
+	if err != nil {
+		return err
+	}
+	objs, err := json2csv.GenerateJSON(schema, c.Int("rows"))
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if c.String("output") != "" {
+		f, err := json2csv.CreateSink(c.String("output"))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	if c.Bool("ndjson") {
+		for _, obj := range objs {
+			if err := enc.Encode(obj); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	enc.SetIndent("", "  ")
+	return enc.Encode(objs)
+}
+
+// readGenSchema reads the JSON Pointers from the schema file, or from the
+// header of the CSV file.
+func readGenSchema(filename string) ([]string, error) {
+	if !strings.EqualFold(filepath.Ext(filename), ".csv") {
+		return readSchemaFile(filename)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%s: no header", filename)
+	}
+	return header, err
+}
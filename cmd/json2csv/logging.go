@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+// verbosity is the level of messages printed to STDERR.
+// Errors are always printed.
+type verbosity int
+
+const (
+	quietLevel   verbosity = iota // -q: nothing
+	normalLevel                   // default
+	verboseLevel                  // -v: summary stats and per-file progress
+	debugLevel                    // -vv: per-record warnings
+)
+
+// logLevel is set by -q, -v and -vv.
+var logLevel = normalLevel
+
+func parseVerbosity(c *cli.Context) (verbosity, error) {
+	switch {
+	case c.Bool("quiet") && (c.Bool("verbose") || c.Bool("vv")):
+		return 0, fmt.Errorf("-q can't be used with -v or -vv")
+	case c.Bool("quiet"):
+		return quietLevel, nil
+	case c.Bool("vv"):
+		return debugLevel, nil
+	case c.Bool("verbose"):
+		return verboseLevel, nil
+	default:
+		return normalLevel, nil
+	}
+}
+
+// logf prints the message if the level is enabled.
+func logf(level verbosity, format string, args ...interface{}) {
+	if logLevel >= level {
+		log.Printf(format, args...)
+	}
+}
+
+// conversionStats is the summary of the conversions.
+type conversionStats struct {
+	mu    sync.Mutex
+	start time.Time
+	files int
+	rows  int
+	bytes int64
+}
+
+var stats = &conversionStats{start: time.Now()}
+
+// Add records an output and prints the progress (-v).
+func (s *conversionStats) Add(source, output string, rows, columns int, size int64) {
+	s.mu.Lock()
+	s.files++
+	s.rows += rows
+	s.bytes += size
+	s.mu.Unlock()
+
+	if output == "" {
+		output = "STDOUT"
+	}
+	logf(verboseLevel, "%s -> %s: %d rows, %d columns, %d bytes", source, output, rows, columns, size)
+}
+
+// Print prints the summary (-v).
+func (s *conversionStats) Print() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	logf(verboseLevel, "%d outputs, %d rows, %d bytes in %s", s.files, s.rows, s.bytes, time.Since(s.start).Round(time.Millisecond))
+}
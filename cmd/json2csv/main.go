@@ -1,14 +1,26 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"plugin"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
-	"github.com/yukithm/json2csv"
-	"github.com/yukithm/json2csv/jsonpointer"
+	"github.com/yukithm/json2csv/v2"
+	"github.com/yukithm/json2csv/v2/jsonpointer"
 
 	"github.com/urfave/cli"
 )
@@ -28,6 +40,49 @@ var headerStyleTable = map[string]json2csv.KeyStyle{
 	"dot-bracket": json2csv.DotBracketStyle,
 }
 
+var dialectTable = map[string]json2csv.Dialect{
+	"csv":   json2csv.CSVDialect,
+	"tsv":   json2csv.TSVDialect,
+	"ascii": json2csv.ASCIIDialect,
+}
+
+var quotingTable = map[string]json2csv.QuoteStyle{
+	"minimal": json2csv.QuoteMinimal,
+	"all":     json2csv.QuoteAll,
+	"none":    json2csv.QuoteNone,
+}
+
+var numericStringsTable = map[string]json2csv.ProtectionStyle{
+	"none":    json2csv.NoProtection,
+	"quote":   json2csv.QuoteProtection,
+	"formula": json2csv.FormulaProtection,
+}
+
+var columnTypeTable = map[string]json2csv.ColumnType{
+	"general": json2csv.GeneralType,
+	"text":    json2csv.TextType,
+	"number":  json2csv.NumberType,
+	"date":    json2csv.DateType,
+}
+
+var headerTruncationTable = map[string]json2csv.TruncationStyle{
+	"truncate": json2csv.TruncateStyle,
+	"hash":     json2csv.HashSuffixStyle,
+}
+
+var binaryTable = map[string]json2csv.BinaryPolicy{
+	"keep":   json2csv.Base64Binary,
+	"hex":    json2csv.HexBinary,
+	"length": json2csv.LengthBinary,
+	"skip":   json2csv.SkipBinary,
+}
+
+// header of the previous output loaded by --previous-header
+var previousHeader []string
+
+// header translations loaded by --header-translations and --locale
+var headerTranslations json2csv.TranslationMap
+
 func main() {
 	// Hide timestamp because this is CLI application, so just print message for users.
 	log.SetFlags(0)
@@ -61,9 +116,16 @@ COPYRIGHT:
 	app := cli.NewApp()
 	app.Name = ApplicationName
 	app.Version = version
+	// -v is for --verbose
+	cli.VersionFlag = cli.BoolFlag{
+		Name:  "version",
+		Usage: "print the version",
+	}
 	app.Usage = "convert JSON to CSV"
 	app.ArgsUsage = "[FILE]"
+	app.UsageText = ApplicationName + " [OPTIONS] [FILE]\n   " + ApplicationName + " gen [OPTIONS] SCHEMA"
 	app.HideHelp = true
+	app.Commands = []cli.Command{genCommand}
 	app.Flags = []cli.Flag{
 		cli.StringFlag{
 			Name:  "header-style",
@@ -78,13 +140,457 @@ COPYRIGHT:
 			Name:  "transpose",
 			Usage: "transpose rows and columns",
 		},
+		cli.StringFlag{
+			Name:  "format",
+			Value: "csv",
+			Usage: "output format (" + strings.Join(json2csv.FormatNames(), ", ") + ")",
+		},
+		cli.StringSliceFlag{
+			Name:  "column-type",
+			Usage: "cell type `COLUMN=TYPE` of the column by header name or JSON Pointer (general, text, number, date) (xlsx format); can be repeated",
+		},
+		cli.StringFlag{
+			Name:  "dialect",
+			Value: "csv",
+			Usage: "output dialect (csv, tsv, ascii)",
+		},
+		cli.StringFlag{
+			Name:  "quoting",
+			Usage: "when fields are quoted (minimal, all, none) (default: depends on --dialect)",
+		},
+		cli.StringSliceFlag{
+			Name:  "quote-column",
+			Usage: "quoting `COLUMN=STYLE` of the column by header name or JSON Pointer (minimal, all, none); can be repeated",
+		},
+		cli.StringSliceFlag{
+			Name:  "rule",
+			Usage: "apply the conditional assignment `RULE` (e.g. 'if /type == refund then /amount := -/amount') to each row; can be repeated and applied in order",
+		},
+		cli.StringSliceFlag{
+			Name:  "transform",
+			Usage: "rewrite string values of the column by `COLUMN=TRANSFORM` (upper, lower, title, unaccent, replace:/PATTERN/REPLACEMENT/, extract:/PATTERN/[GROUP]) by header name or JSON Pointer; can be repeated and applied in order",
+		},
+		cli.StringSliceFlag{
+			Name:  "column-format",
+			Usage: "number format `COLUMN=FORMAT` of the column by header name or JSON Pointer (currency:SYMBOL[:MINOR_UNITS], bytes[:UNIT[.PRECISION]]); can be repeated",
+		},
+		cli.StringFlag{
+			Name:  "numeric-strings",
+			Value: "none",
+			Usage: "protect numeric-looking strings with leading zeros or long digit runs from spreadsheets (none, quote, formula)",
+		},
+		cli.StringSliceFlag{
+			Name:  "comment",
+			Usage: "write a comment line `TEMPLATE` before the header (fields: Source, Rows, Date, Time); can be repeated",
+		},
+		cli.StringFlag{
+			Name:  "comment-prefix",
+			Value: "# ",
+			Usage: "prefix of comment lines",
+		},
+		cli.BoolFlag{
+			Name:  "strict",
+			Usage: "write strict RFC 4180 output without comment lines",
+		},
+		cli.BoolFlag{
+			Name:  "self-check",
+			Usage: "re-parse the output strictly by RFC 4180 and fail (removing the output file) unless it has the written rows and columns",
+		},
+		cli.StringFlag{
+			Name:  "record-separator",
+			Usage: "record terminator `STR` instead of LF (escapes like \\x1e, \\0 and \\r\\n are accepted)",
+		},
+		cli.BoolFlag{
+			Name:  "quote-empty",
+			Usage: "quote empty strings (\"\") to distinguish them from null or missing values",
+		},
+		cli.BoolFlag{
+			Name:  "trim",
+			Usage: "remove leading and trailing white space of string values",
+		},
+		cli.BoolFlag{
+			Name:  "collapse-space",
+			Usage: "replace each run of white space (including line breaks) in string values with a single space",
+		},
+		cli.BoolFlag{
+			Name:  "nfc",
+			Usage: "normalize string values to Unicode NFC",
+		},
+		cli.BoolFlag{
+			Name:  "group-header",
+			Usage: "write a two-row header: the top-level key and the rest of the path",
+		},
+		cli.StringFlag{
+			Name:  "header-translations",
+			Usage: "translate header names by `FILE` (JSON object of locales to objects of keys or names to translations)",
+		},
+		cli.StringFlag{
+			Name:  "locale",
+			Usage: "`LOCALE` of --header-translations (e.g. fr, pt-BR)",
+		},
+		cli.StringFlag{
+			Name:  "header-prefix",
+			Usage: "add `STR` to the beginning of every header name",
+		},
+		cli.StringFlag{
+			Name:  "header-suffix",
+			Usage: "add `STR` to the end of every header name",
+		},
+		cli.IntFlag{
+			Name:  "max-header-length",
+			Usage: "maximum length of header names in bytes (0 means no limit)",
+		},
+		cli.StringFlag{
+			Name:  "header-truncation",
+			Value: "truncate",
+			Usage: "how to shorten long header names (truncate, hash)",
+		},
+		cli.StringFlag{
+			Name:  "decoder",
+			Value: "std",
+			Usage: "JSON decoder backend (" + strings.Join(json2csv.DecoderNames(), ", ") + ")",
+		},
+		cli.BoolFlag{
+			Name:  "mmap",
+			Usage: "read input files via memory mapping",
+		},
+		cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "abort reading and converting each input exceeding `DURATION` (e.g. 30s)",
+		},
+		cli.StringFlag{
+			Name:  "binary",
+			Value: "keep",
+			Usage: "how to convert base64 strings detected by --binary-detect or --binary-column (keep, hex, length, skip)",
+		},
+		cli.BoolFlag{
+			Name:  "binary-detect",
+			Usage: "detect base64 strings of at least --binary-min-length characters in all columns",
+		},
+		cli.StringSliceFlag{
+			Name:  "binary-column",
+			Usage: "detect base64 strings only in the column by JSON `POINTER`; can be repeated",
+		},
+		cli.IntFlag{
+			Name:  "binary-min-length",
+			Value: 64,
+			Usage: "minimum length of base64 strings detected by --binary-detect or --binary-column",
+		},
+		cli.StringFlag{
+			Name:  "max-cell-size",
+			Usage: "limit cell values to `SIZE` (e.g. 32KB); larger values are truncated or moved to --overflow-file",
+		},
+		cli.StringFlag{
+			Name:  "overflow-file",
+			Usage: "write cell values exceeding --max-cell-size to `FILE` as JSON lines, leaving overflow:ROW:KEY in the cells",
+		},
+		cli.StringFlag{
+			Name:  "max-memory",
+			Usage: "fail if the input file or the converted rows exceed `SIZE` (e.g. 512MB); the decoded JSON itself is not counted",
+		},
+		cli.IntFlag{
+			Name:  "parallel",
+			Value: 1,
+			Usage: "flatten and write the rows in chunks by `N` goroutines, keeping the order",
+		},
+		cli.IntFlag{
+			Name:  "expect-rows",
+			Usage: "fail unless the output has exactly `N` rows",
+		},
+		cli.IntFlag{
+			Name:  "min-rows",
+			Usage: "fail if the output has less than `N` rows",
+		},
+		cli.IntFlag{
+			Name:  "max-rows",
+			Usage: "fail if the output has more than `N` rows",
+		},
+		cli.StringFlag{
+			Name:  "min-bytes",
+			Usage: "fail if the output is smaller than `SIZE` (e.g. 1KB)",
+		},
+		cli.StringFlag{
+			Name:  "max-bytes",
+			Usage: "fail if the output is larger than `SIZE` (e.g. 1GB)",
+		},
+		cli.StringSliceFlag{
+			Name:  "plugin",
+			Usage: "load the Go plugin `FILE` which registers sources, sinks and formats; can be repeated",
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "write CSV to `FILE` instead of STDOUT (may be a template with fields: Partition, Stem, Name, Ext, Date, Time)",
+		},
+		cli.StringFlag{
+			Name:  "partition-by",
+			Usage: "split rows into outputs by the value of the column `POINTER` (--output is a template with Partition field)",
+		},
+		cli.BoolFlag{
+			Name:  "drop-empty-columns",
+			Usage: "drop the columns which have no values in each partition instead of writing all columns (--partition-by mode)",
+		},
+		cli.StringFlag{
+			Name:  "cache",
+			Usage: "skip conversion if the input is unchanged since the last run recorded in `FILE` (requires --output or --output-dir)",
+		},
+		cli.BoolFlag{
+			Name:  "combine",
+			Usage: "convert multiple inputs into a single CSV",
+		},
+		cli.StringFlag{
+			Name:  "output-name",
+			Value: "{{.Stem}}.csv",
+			Usage: "output file name `TEMPLATE` in --output-dir (fields: Stem, Name, Ext, Index, Date, Time)",
+		},
+		cli.BoolFlag{
+			Name:  "stream",
+			Usage: "convert a sequence of JSON values (e.g. NDJSON) and write each row immediately",
+		},
+		cli.StringFlag{
+			Name:  "columns",
+			Usage: "comma separated JSON Pointers of the header (--stream mode)",
+		},
+		cli.StringFlag{
+			Name:  "on-interrupt",
+			Value: "keep",
+			Usage: "what to do with the output file being written when interrupted by a signal (keep, partial, remove)",
+		},
+		cli.StringFlag{
+			Name:  "socket",
+			Usage: "write the stream to the first client of the UNIX socket `PATH` (--stream mode)",
+		},
+		cli.DurationFlag{
+			Name:  "rotate",
+			Usage: "write a new output file every `DURATION` (e.g. 1h) named by the --output template (--stream mode) or --output-name (--watch mode)",
+		},
+		cli.StringFlag{
+			Name:  "watch",
+			Usage: "watch `DIR` and convert each new JSON file into --output-dir",
+		},
+		cli.BoolFlag{
+			Name:  "estimate",
+			Usage: "print the estimated rows, columns and output size from a sample of each input instead of converting",
+		},
+		cli.BoolFlag{
+			Name:  "analyze",
+			Usage: "print the cardinality and the width of each column of each input as JSON instead of converting",
+		},
+		cli.IntFlag{
+			Name:  "estimate-sample",
+			Value: 1000,
+			Usage: "number of rows sampled by --estimate",
+		},
+		cli.StringFlag{
+			Name:  "output-dir",
+			Usage: "write one CSV per input into `DIR`",
+		},
+		cli.StringFlag{
+			Name:  "ledger",
+			Usage: "record processed files in `FILE` (--watch mode)",
+		},
+		cli.IntFlag{
+			Name:  "concurrency",
+			Value: 1,
+			Usage: "maximum number of concurrent conversions (--watch mode)",
+		},
+		cli.DurationFlag{
+			Name:  "interval",
+			Value: 2 * time.Second,
+			Usage: "polling interval (--watch mode)",
+		},
+		cli.StringFlag{
+			Name:  "columns-first",
+			Usage: "comma separated header names or JSON Pointers of the columns moved to the front",
+		},
+		cli.StringFlag{
+			Name:  "golden-schema",
+			Usage: "fail without output if the JSON Pointers of the columns differ from the golden schema `FILE`",
+		},
+		cli.BoolFlag{
+			Name:  "update-golden-schema",
+			Usage: "write the JSON Pointers of the columns to the --golden-schema file",
+		},
+		cli.StringFlag{
+			Name:  "previous-header",
+			Usage: "keep the column order of the previous output `FILE` and append new columns at the end",
+		},
+		cli.StringFlag{
+			Name:  "mapping-file",
+			Usage: "write the mapping from JSON Pointer to header name to `FILE`",
+		},
+		cli.BoolFlag{
+			Name:  "quiet, q",
+			Usage: "print nothing but errors",
+		},
+		cli.BoolFlag{
+			Name:  "verbose, v",
+			Usage: "print summary stats and per-file progress to STDERR",
+		},
+		cli.BoolFlag{
+			Name:  "vv",
+			Usage: "print per-record warnings in addition to -v",
+		},
 		cli.HelpFlag,
 	}
 
 	app.Before = func(c *cli.Context) error {
+		var err error
+		if logLevel, err = parseVerbosity(c); err != nil {
+			return err
+		}
+		// Load plugins first, they may register decoders too.
+		for _, filename := range c.StringSlice("plugin") {
+			if _, err := plugin.Open(filename); err != nil {
+				return fmt.Errorf("Failed to load --plugin %q: %s", filename, err)
+			}
+		}
 		if _, ok := headerStyleTable[c.String("header-style")]; !ok {
 			return fmt.Errorf("Invalid --header-style value %q", c.String("header-style"))
 		}
+		if _, ok := json2csv.LookupDecoder(c.String("decoder")); !ok {
+			return fmt.Errorf("Invalid --decoder value %q", c.String("decoder"))
+		}
+		if _, ok := headerTruncationTable[c.String("header-truncation")]; !ok {
+			return fmt.Errorf("Invalid --header-truncation value %q", c.String("header-truncation"))
+		}
+		if _, err := newOutputNamer(c.String("output-name"), time.Now()); err != nil {
+			return fmt.Errorf("Invalid --output-name value %q: %s", c.String("output-name"), err)
+		}
+		if _, ok := binaryTable[c.String("binary")]; !ok {
+			return fmt.Errorf("Invalid --binary value %q", c.String("binary"))
+		}
+		if c.String("binary") != "keep" && !c.Bool("binary-detect") && len(c.StringSlice("binary-column")) == 0 {
+			return fmt.Errorf("--binary requires --binary-detect or --binary-column")
+		}
+		if c.Int("binary-min-length") <= 0 {
+			return fmt.Errorf("Invalid --binary-min-length value %d", c.Int("binary-min-length"))
+		}
+		if c.Int("parallel") < 1 {
+			return fmt.Errorf("Invalid --parallel value %d", c.Int("parallel"))
+		}
+		if c.String("max-memory") != "" {
+			if _, err := parseSize(c.String("max-memory")); err != nil {
+				return fmt.Errorf("Invalid --max-memory value %q", c.String("max-memory"))
+			}
+		}
+		if _, ok := json2csv.LookupFormat(c.String("format")); !ok {
+			return fmt.Errorf("Invalid --format value %q", c.String("format"))
+		}
+		if c.String("format") != "csv" && (c.Bool("stream") || c.Bool("transpose")) {
+			return fmt.Errorf("--format=%s can't be used with --stream or --transpose", c.String("format"))
+		}
+		if _, err := parseColumnTypes(c.StringSlice("column-type")); err != nil {
+			return err
+		}
+		if _, err := renderComments(c.StringSlice("comment"), "", 0, time.Now()); err != nil {
+			return fmt.Errorf("Invalid --comment value: %s", err)
+		}
+		for _, name := range []string{"min-bytes", "max-bytes"} {
+			if _, err := parseSize(c.String(name)); c.IsSet(name) && err != nil {
+				return fmt.Errorf("Invalid --%s value %q", name, c.String(name))
+			}
+		}
+		if c.String("partition-by") != "" && !isOutputTemplate(c.String("output")) {
+			return fmt.Errorf("--partition-by requires --output template (e.g. out/{{.Partition}}.csv)")
+		}
+		if c.Bool("drop-empty-columns") && c.String("partition-by") == "" {
+			return fmt.Errorf("--drop-empty-columns requires --partition-by")
+		}
+		if isOutputTemplate(c.String("output")) {
+			if _, err := newOutputNamer(c.String("output"), time.Now()); err != nil {
+				return fmt.Errorf("Invalid --output value %q: %s", c.String("output"), err)
+			}
+			if c.Bool("stream") && c.Duration("rotate") == 0 {
+				return fmt.Errorf("--output template can't be used with --stream without --rotate")
+			}
+		}
+		if err := checkRotate(c); err != nil {
+			return err
+		}
+		switch c.String("on-interrupt") {
+		case "keep", "partial", "remove":
+		default:
+			return fmt.Errorf("Invalid --on-interrupt value %q", c.String("on-interrupt"))
+		}
+		if c.Duration("timeout") < 0 {
+			return fmt.Errorf("Invalid --timeout value %s", c.Duration("timeout"))
+		}
+		if c.Bool("update-golden-schema") && c.String("golden-schema") == "" {
+			return fmt.Errorf("--update-golden-schema requires --golden-schema")
+		}
+		if c.Bool("stream") && c.String("golden-schema") != "" {
+			return fmt.Errorf("--golden-schema can't be used with --stream")
+		}
+		if c.Bool("stream") && hasOutputAssertions(c) {
+			return fmt.Errorf("--expect-rows, --min-rows, --max-rows, --min-bytes and --max-bytes can't be used with --stream")
+		}
+		if _, ok := dialectTable[c.String("dialect")]; !ok {
+			return fmt.Errorf("Invalid --dialect value %q", c.String("dialect"))
+		}
+		if _, ok := quotingTable[c.String("quoting")]; !ok && c.String("quoting") != "" {
+			return fmt.Errorf("Invalid --quoting value %q", c.String("quoting"))
+		}
+		if _, ok := numericStringsTable[c.String("numeric-strings")]; !ok {
+			return fmt.Errorf("Invalid --numeric-strings value %q", c.String("numeric-strings"))
+		}
+		if _, err := parseColumnQuoting(c.StringSlice("quote-column")); err != nil {
+			return err
+		}
+		if _, err := parseColumnFormats(c.StringSlice("column-format")); err != nil {
+			return err
+		}
+		if _, err := parseColumnTransforms(c.StringSlice("transform")); err != nil {
+			return err
+		}
+		if _, err := parseRules(c.StringSlice("rule")); err != nil {
+			return err
+		}
+		if _, err := parseSeparator(c.String("record-separator")); err != nil {
+			return fmt.Errorf("Invalid --record-separator value %q", c.String("record-separator"))
+		}
+		if c.String("header-translations") != "" || c.String("locale") != "" {
+			if c.String("header-translations") == "" || c.String("locale") == "" {
+				return fmt.Errorf("--header-translations and --locale must be used together")
+			}
+			var err error
+			headerTranslations, err = readHeaderTranslations(c.String("header-translations"), c.String("locale"))
+			if err != nil {
+				return err
+			}
+		}
+		if c.String("previous-header") != "" {
+			// Load before the output (which may be the same file) is overwritten.
+			var err error
+			previousHeader, err = readCSVHeader(c.String("previous-header"), c)
+			if err != nil {
+				return err
+			}
+		}
+		if c.String("max-cell-size") != "" {
+			if n, err := parseSize(c.String("max-cell-size")); err != nil || n <= 0 {
+				return fmt.Errorf("Invalid --max-cell-size value %q", c.String("max-cell-size"))
+			}
+		}
+		if c.String("overflow-file") != "" {
+			if c.String("max-cell-size") == "" {
+				return fmt.Errorf("--overflow-file requires --max-cell-size")
+			}
+			if c.String("output-dir") != "" || c.String("watch") != "" || isOutputTemplate(c.String("output")) {
+				return fmt.Errorf("--overflow-file can't be used with multiple outputs")
+			}
+		}
+		if c.Bool("self-check") && (c.Bool("stream") || c.String("format") != "csv") {
+			return fmt.Errorf("--self-check can't be used with --stream or --format other than csv")
+		}
+		if c.Bool("analyze") && (c.Bool("estimate") || c.Bool("stream") || c.String("watch") != "") {
+			return fmt.Errorf("--analyze can't be used with --estimate, --stream or --watch")
+		}
+		if c.Int("estimate-sample") <= 0 {
+			return fmt.Errorf("Invalid --estimate-sample value %d", c.Int("estimate-sample"))
+		}
+		if c.Int("max-header-length") < 0 {
+			return fmt.Errorf("Invalid --max-header-length value %d", c.Int("max-header-length"))
+		}
 		return nil
 	}
 
@@ -100,67 +606,767 @@ COPYRIGHT:
 }
 
 func mainAction(c *cli.Context) {
-	var data interface{}
+	handleSignals()
+
 	var err error
-	if c.NArg() > 0 && c.Args()[0] != "-" {
-		data, err = readJSONFile(c.Args()[0])
-	} else {
-		data, err = readJSON(os.Stdin)
+	switch {
+	case c.Bool("estimate"):
+		err = estimateAction(inputArgs(c), c)
+	case c.Bool("analyze"):
+		err = analyzeAction(inputArgs(c), c)
+	default:
+		if err = openOverflow(c); err != nil {
+			break
+		}
+		err = convertAction(c)
+		// Close the --overflow-file before exiting, even on errors.
+		if closeErr := closeOverflow(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		exitOnError(err)
+	}
+	if interrupted() {
+		// e.g. stopped between inputs without an error
+		exitInterrupted()
+	}
+	if c.String("watch") == "" && !c.Bool("estimate") {
+		stats.Print()
+	}
+}
+
+// inputArgs returns the input files of the arguments, or STDIN ("-").
+func inputArgs(c *cli.Context) []string {
+	inputs := []string(c.Args())
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+	return inputs
+}
+
+// convertAction converts the inputs by --watch, --stream, --combine,
+// --output-dir or a single input.
+func convertAction(c *cli.Context) error {
+	if c.String("watch") != "" {
+		return watchAction(c)
+	}
+	if c.Bool("stream") {
+		return streamAction(c)
+	}
+
+	inputs := inputArgs(c)
+	switch {
+	case c.Bool("combine"):
+		return convertCombined(inputs, c.String("output"), c)
+	case c.String("output-dir") != "":
+		return convertEach(inputs, c)
+	case len(inputs) > 1:
+		return fmt.Errorf("Multiple inputs require --output-dir or --combine")
+	default:
+		return convertCached(inputs[0], c.String("output"), c)
+	}
+}
+
+// exitOnError prints the error and exits, with the signal exit code if
+// interrupted.
+func exitOnError(err error) {
+	if interrupted() {
+		exitInterrupted()
+	}
+	log.Fatal(err)
+}
+
+// convertCached converts the input unless the checksum cache (--cache) tells
+// it is unchanged.
+func convertCached(input, output string, c *cli.Context) error {
+	if c.String("cache") == "" || input == "-" || output == "" || !json2csv.IsLocalLocation(input) {
+		return convert(input, output, c)
+	}
+
+	cache, err := loadChecksumCache(c.String("cache"), conversionHash(c))
+	if err != nil {
+		return err
+	}
+	checksum, err := fileChecksum(input)
+	if err != nil {
+		return err
+	}
+	if cache.Unchanged(input, output, checksum) {
+		return nil
+	}
+
+	if err := convert(input, output, c); err != nil {
+		return err
+	}
+	cache.Update(input, output, checksum, nil)
+	return cache.Save()
+}
+
+// convert converts the input file ("-" means STDIN) and writes CSV to the
+// output file (empty means STDOUT).
+func convert(input, output string, c *cli.Context) error {
+	results, err := readResults(input, c)
+	if err != nil {
+		return err
+	}
+	return writeResults(results, sourceName(input), sourceName(input), output, c)
+}
+
+// readResults reads the input file ("-" means STDIN) and flattens it.
+// It is aborted if it exceeds --timeout.
+func readResults(input string, c *cli.Context) ([]json2csv.KeyValue, error) {
+	ctx := interruptContext
+	if c.Duration("timeout") > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Duration("timeout"))
+		defer cancel()
+	}
+
+	results, err := readResultsContext(ctx, input, c)
+	if err == context.DeadlineExceeded {
+		return nil, timeoutError(c)
+	} else if err == context.Canceled {
+		return nil, errInterrupted
+	}
+	return results, err
+}
+
+// checkInputSize fails before decoding if the local input file is larger than
+// --max-memory, since the decoded JSON is held in memory in addition to the
+// rows counted by json2csv.Options.MaxMemory.
+func checkInputSize(input string, c *cli.Context) error {
+	if c.String("max-memory") == "" || input == "-" || !json2csv.IsLocalLocation(input) {
+		return nil
+	}
+	// already validated
+	limit, _ := parseSize(c.String("max-memory"))
+	fi, err := os.Stat(json2csv.LocalPath(input))
+	if err != nil || !fi.Mode().IsRegular() {
+		// reported by reading it
+		return nil
+	}
+	if fi.Size() > limit {
+		return fmt.Errorf("Input is %d bytes, larger than --max-memory (%d bytes); use --stream or split the input", fi.Size(), limit)
+	}
+	return nil
+}
+
+// timeoutError is the error of an input exceeding --timeout.
+func timeoutError(c *cli.Context) error {
+	return fmt.Errorf("Timed out after %s", c.Duration("timeout"))
+}
+
+func readResultsContext(ctx context.Context, input string, c *cli.Context) ([]json2csv.KeyValue, error) {
+	if err := checkInputSize(input, c); err != nil {
+		return nil, err
 	}
+	data, err := readInputContext(ctx, input, c)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	if c.String("path") != "" {
 		data, err = jsonpointer.Get(data, c.String("path"))
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 	}
 
-	results, err := json2csv.JSON2CSV(data)
-	if err != nil {
-		log.Fatal(err)
+	// already validated
+	conv, _ := json2csv.NewConverter(conversionOptions(c))
+	results, err := conv.Flatten(ctx, data)
+	if _, ok := err.(*json2csv.MemoryLimitError); ok {
+		return nil, fmt.Errorf("%s; use --stream or split the input", err)
+	}
+	return results, err
+}
+
+// conversionOptions returns the conversion options by the command line options.
+func conversionOptions(c *cli.Context) json2csv.Options {
+	var opts json2csv.Options
+	if c.String("max-memory") != "" {
+		// already validated
+		opts.MaxMemory, _ = parseSize(c.String("max-memory"))
 	}
-	if len(results) == 0 {
-		return
+	// already validated
+	opts.Rules, _ = parseRules(c.StringSlice("rule"))
+	opts.Parallelism = c.Int("parallel")
+	opts.Flatten.Binary = binaryTable[c.String("binary")]
+	if c.Bool("binary-detect") || len(c.StringSlice("binary-column")) > 0 {
+		opts.Flatten.Base64MinLength = c.Int("binary-min-length")
+		opts.Flatten.Base64Keys = c.StringSlice("binary-column")
 	}
+	return opts
+}
 
-	headerStyle := headerStyleTable[c.String("header-style")]
-	err = printCSV(os.Stdout, results, headerStyle, c.Bool("transpose"))
-	if err != nil {
-		log.Fatal(err)
+// writeResults writes CSV to the output file (empty means STDOUT).
+// The source is the name of the inputs used in the comments, and the input
+// is the file name used in the output template.
+// If the output doesn't meet the assertions (e.g. --expect-rows), the output
+// file is removed.
+func writeResults(results []json2csv.KeyValue, source string, input string, output string, c *cli.Context) error {
+	if err := checkGoldenSchema(results, c); err != nil {
+		return err
+	}
+
+	if isOutputTemplate(output) || c.String("partition-by") != "" {
+		if err := writePartitions(results, source, input, output, c); err != nil {
+			return err
+		}
+	} else if output != "" {
+		size, err := writeCSVFile(output, source, results, nil, c)
+		if err != nil {
+			return err
+		}
+		if err := checkOutput(len(results), size, c); err != nil {
+			if json2csv.IsLocalLocation(output) {
+				os.Remove(json2csv.LocalPath(output))
+			}
+			return err
+		}
+		stats.Add(source, output, len(results), columnCount(results, c), size)
+	} else {
+		stdout := &countingWriter{w: os.Stdout}
+		if len(results) > 0 {
+			if err := printCSV(stdout, source, results, nil, c); err != nil {
+				return err
+			}
+		}
+		if err := checkOutput(len(results), stdout.n, c); err != nil {
+			return err
+		}
+		stats.Add(source, "", len(results), columnCount(results, c), stdout.n)
 	}
+
+	if len(results) > 0 && c.String("mapping-file") != "" {
+		if err := writeMappingFile(c.String("mapping-file"), results, c); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func readJSONFile(filename string) (interface{}, error) {
-	f, err := os.Open(filename)
+// readInputContext reads the input file ("-" means STDIN) until ctx is done.
+// The input is closed when ctx is done, so that reading from a hung source
+// fails and doesn't leak the goroutine and the file.
+func readInputContext(ctx context.Context, input string, c *cli.Context) (interface{}, error) {
+	type result struct {
+		data interface{}
+		err  error
+	}
+	in := &cancelableInput{}
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		r.data, r.err = in.read(input, c)
+		done <- r
+	}()
+
+	select {
+	case r := <-done:
+		return r.data, r.err
+	case <-ctx.Done():
+		in.cancel()
+		return nil, ctx.Err()
+	}
+}
+
+// cancelableInput is an input which can be closed while it is read.
+type cancelableInput struct {
+	mu       sync.Mutex
+	closer   io.Closer
+	canceled bool
+}
+
+// read reads the input file ("-" means STDIN).
+func (in *cancelableInput) read(input string, c *cli.Context) (interface{}, error) {
+	if input == "-" {
+		if !in.setCloser(os.Stdin) {
+			return nil, context.Canceled
+		}
+		return readJSON(os.Stdin, c)
+	}
+
+	f, err := openInput(input, c.Bool("mmap"))
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+	closer := &onceCloser{Closer: f}
+	defer closer.Close()
+	if m, ok := f.(*mappedReader); ok {
+		// Reading the mapped file doesn't block, and it must not be unmapped
+		// while it is read.
+		return readMapped(m, c)
+	}
+	if !in.setCloser(closer) {
+		return nil, context.Canceled
+	}
+	return readJSON(f, c)
+}
+
+// setCloser sets the closer of the input, or returns false if it has been
+// canceled.
+func (in *cancelableInput) setCloser(closer io.Closer) bool {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.canceled {
+		return false
+	}
+	in.closer = closer
+	return true
+}
 
-	return readJSON(f)
+// cancel closes the input being read.
+func (in *cancelableInput) cancel() {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.canceled = true
+	if in.closer != nil {
+		in.closer.Close()
+	}
 }
 
-func readJSON(r io.Reader) (interface{}, error) {
-	decoder := json.NewDecoder(r)
-	decoder.UseNumber()
+// onceCloser closes the Closer only once.
+type onceCloser struct {
+	io.Closer
+	once sync.Once
+	err  error
+}
 
-	var data interface{}
-	if err := decoder.Decode(&data); err != nil {
+func (c *onceCloser) Close() error {
+	c.once.Do(func() {
+		c.err = c.Closer.Close()
+	})
+	return c.err
+}
+
+// openInput opens the input file. If mmap is true, the file is memory-mapped.
+func openInput(filename string, mmap bool) (io.ReadCloser, error) {
+	if !json2csv.IsLocalLocation(filename) {
+		return json2csv.OpenSource(filename)
+	}
+	filename = json2csv.LocalPath(filename)
+	if !mmap {
+		return os.Open(filename)
+	}
+
+	m, err := openMappedFile(filename)
+	if err != nil {
 		return nil, err
 	}
+	return &mappedReader{Reader: bytes.NewReader(m.Bytes()), file: m}, nil
+}
+
+// mappedReader reads the memory-mapped file. Reading it copies the data like
+// a regular file; use readMapped to decode the file in place.
+type mappedReader struct {
+	*bytes.Reader
+	file *mappedFile
 
-	return data, nil
+	// mu keeps the file mapped while it is read by another goroutine, e.g.
+	// the stream decoder after a timeout.
+	mu     sync.RWMutex
+	closed bool
 }
 
-func printCSV(w io.Writer, results []json2csv.KeyValue, headerStyle json2csv.KeyStyle, transpose bool) error {
+func (r *mappedReader) Read(p []byte) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+	return r.Reader.Read(p)
+}
+
+func (r *mappedReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.file.Close()
+}
+
+func readJSON(r io.Reader, c *cli.Context) (interface{}, error) {
+	return newDecoder(r, c).Decode()
+}
+
+// readMapped decodes the memory-mapped file directly from the mapping if the
+// decoder backend supports it, otherwise reads it like readJSON (e.g. "std",
+// so that the result doesn't depend on --mmap).
+func readMapped(m *mappedReader, c *cli.Context) (interface{}, error) {
+	decode, ok := mappedDecoders[c.String("decoder")]
+	if !ok {
+		return readJSON(m, c)
+	}
+	return decode(m.file.Bytes())
+}
+
+// newDecoder returns the decoder backend specified by --decoder.
+func newDecoder(r io.Reader, c *cli.Context) json2csv.Decoder {
+	// already validated
+	factory, _ := json2csv.LookupDecoder(c.String("decoder"))
+	return factory(r)
+}
+
+// printCSV writes CSV (or the format of --format) to w by the registered
+// RecordWriter of the format.
+// If header is not nil, it is used as the PreviousHeader: its columns come
+// first in its order (empty if missing in the results), and the other
+// columns of the results are appended after them.
+func printCSV(w io.Writer, source string, results []json2csv.KeyValue, header []string, c *cli.Context) error {
+	// already validated
+	format, _ := json2csv.LookupFormat(c.String("format"))
+	rw := format(w)
+	if xlsx, ok := rw.(*json2csv.XLSXRecordWriter); ok {
+		// already validated
+		xlsx.NamedColumnTypes, _ = parseColumnTypes(c.StringSlice("column-type"))
+	}
+
+	csv := newCSVWriter(w, c)
+	if header != nil {
+		csv.PreviousHeader = header
+	}
+	if err := setComments(csv, source, len(results), c); err != nil {
+		return err
+	}
+	if dw, ok := rw.(json2csv.DelimitedRecordWriter); ok {
+		// --dialect, --quoting and --record-separator apply to the delimited
+		// formats, but the delimiter of the other formats (e.g. the tab of
+		// tsv) is kept.
+		out := dw.Writer()
+		if c.String("format") == "csv" {
+			out.Comma = csv.Comma
+		}
+		out.Terminator, out.Quoting = csv.Terminator, csv.Quoting
+	}
+	if err := csv.WriteRecords(rw, results); err != nil {
+		return err
+	}
+	return rw.Close()
+}
+
+// newCSVWriter returns a CSVWriter configured by the command line options.
+func newCSVWriter(w io.Writer, c *cli.Context) *json2csv.CSVWriter {
 	csv := json2csv.NewCSVWriter(w)
-	csv.HeaderStyle = headerStyle
-	csv.Transpose = transpose
-	if err := csv.WriteCSV(results); err != nil {
+	configureCSVWriter(csv, c)
+	return csv
+}
+
+// configureCSVWriter configures the CSVWriter by the command line options.
+func configureCSVWriter(csv *json2csv.CSVWriter, c *cli.Context) {
+	csv.HeaderStyle = headerStyleTable[c.String("header-style")]
+	csv.Transpose = c.Bool("transpose")
+	csv.Parallelism = c.Int("parallel")
+	csv.QuoteEmpty = c.Bool("quote-empty")
+	csv.SetDialect(dialectTable[c.String("dialect")])
+	if c.String("quoting") != "" {
+		csv.Quoting = quotingTable[c.String("quoting")]
+	}
+	csv.NumericStrings = numericStringsTable[c.String("numeric-strings")]
+	// already validated
+	csv.ColumnQuoting, _ = parseColumnQuoting(c.StringSlice("quote-column"))
+	csv.ColumnFormats, _ = parseColumnFormats(c.StringSlice("column-format"))
+	csv.ColumnTransforms, _ = parseColumnTransforms(c.StringSlice("transform"))
+	if c.String("record-separator") != "" {
+		// already validated
+		csv.Terminator, _ = parseSeparator(c.String("record-separator"))
+	}
+	csv.SelfCheck = c.Bool("self-check")
+	csv.TrimSpace = c.Bool("trim")
+	csv.CollapseSpace = c.Bool("collapse-space")
+	csv.NormalizeUnicode = c.Bool("nfc")
+	csv.GroupHeader = c.Bool("group-header")
+	if headerTranslations != nil {
+		csv.Translator = headerTranslations
+	}
+	csv.HeaderPrefix = c.String("header-prefix")
+	csv.HeaderSuffix = c.String("header-suffix")
+	csv.MaxHeaderLength = c.Int("max-header-length")
+	csv.HeaderTruncation = headerTruncationTable[c.String("header-truncation")]
+	csv.PreviousHeader = previousHeader
+	if c.String("columns-first") != "" {
+		csv.ColumnsFirst = strings.Split(c.String("columns-first"), ",")
+	}
+	if c.String("max-cell-size") != "" {
+		// already validated
+		size, _ := parseSize(c.String("max-cell-size"))
+		csv.MaxCellSize = int(size)
+		if overflow != nil {
+			csv.Overflow = overflow
+		}
+	}
+}
+
+// setComments sets the comments (--comment) unless --strict is set.
+func setComments(csv *json2csv.CSVWriter, source string, rows int, c *cli.Context) error {
+	if c.Bool("strict") || len(c.StringSlice("comment")) == 0 {
+		return nil
+	}
+
+	comments, err := renderComments(c.StringSlice("comment"), source, rows, time.Now())
+	if err != nil {
 		return err
 	}
+	csv.Comments = comments
+	csv.CommentPrefix = c.String("comment-prefix")
 	return nil
 }
+
+// sourceName returns the name of the input used in the comments.
+func sourceName(input string) string {
+	if input == "-" {
+		return "stdin"
+	}
+	return filepath.Base(input)
+}
+
+// parseColumnQuoting parses "COLUMN=STYLE" values.
+// The column may contain "=", so the last one separates the style.
+func parseColumnQuoting(values []string) (map[string]json2csv.QuoteStyle, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	quoting := make(map[string]json2csv.QuoteStyle, len(values))
+	for _, v := range values {
+		i := strings.LastIndex(v, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("Invalid --quote-column value %q", v)
+		}
+		style, ok := quotingTable[v[i+1:]]
+		if !ok {
+			return nil, fmt.Errorf("Invalid --quote-column value %q", v)
+		}
+		quoting[v[:i]] = style
+	}
+	return quoting, nil
+}
+
+// parseColumnFormats parses "COLUMN=FORMAT" values.
+// The column may contain "=", so the last one separates the format.
+func parseColumnFormats(values []string) (map[string]json2csv.NumberFormat, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	formats := make(map[string]json2csv.NumberFormat, len(values))
+	for _, v := range values {
+		i := strings.LastIndex(v, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("Invalid --column-format value %q", v)
+		}
+		f, err := json2csv.ParseNumberFormat(v[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid --column-format value %q", v)
+		}
+		formats[v[:i]] = f
+	}
+	return formats, nil
+}
+
+// parseColumnTransforms parses "COLUMN=TRANSFORM" values. The transforms of
+// the same column are applied in order.
+// Unlike the other options, the first "=" separates the transform, since
+// regular expressions may contain "=".
+func parseColumnTransforms(values []string) (map[string][]json2csv.Transform, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	transforms := make(map[string][]json2csv.Transform, len(values))
+	for _, v := range values {
+		i := strings.Index(v, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("Invalid --transform value %q", v)
+		}
+		t, err := json2csv.ParseTransform(v[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid --transform value %q", v)
+		}
+		transforms[v[:i]] = append(transforms[v[:i]], t)
+	}
+	return transforms, nil
+}
+
+// parseRules parses --rule values.
+func parseRules(values []string) ([]*json2csv.Rule, error) {
+	var rules []*json2csv.Rule
+	for _, v := range values {
+		r, err := json2csv.ParseRule(v)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// parseColumnTypes parses "COLUMN=TYPE" values.
+// The column may contain "=", so the last one separates the type.
+func parseColumnTypes(values []string) (map[string]json2csv.ColumnType, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	types := make(map[string]json2csv.ColumnType, len(values))
+	for _, v := range values {
+		i := strings.LastIndex(v, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("Invalid --column-type value %q", v)
+		}
+		t, ok := columnTypeTable[v[i+1:]]
+		if !ok {
+			return nil, fmt.Errorf("Invalid --column-type value %q", v)
+		}
+		types[v[:i]] = t
+	}
+	return types, nil
+}
+
+// parseSeparator parses a separator string with Go escape sequences.
+// "\0" means NUL.
+func parseSeparator(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	s = strings.Replace(s, `\0`, `\x00`, -1)
+	if strings.Contains(s, `"`) {
+		return "", fmt.Errorf("Invalid separator %q", s)
+	}
+	return strconv.Unquote(`"` + s + `"`)
+}
+
+// readCSVHeader reads the header (the first record) of the CSV file written
+// in the dialect of the options. A missing or empty file has no header.
+func readCSVHeader(filename string, c *cli.Context) ([]string, error) {
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Skip the comment lines written by --comment.
+	var comment string
+	if len(c.StringSlice("comment")) > 0 && !c.Bool("strict") {
+		comment = c.String("comment-prefix")
+	}
+
+	w := newCSVWriter(ioutil.Discard, c)
+	if w.Terminator != "" && w.Terminator != "\n" && w.Terminator != "\r\n" {
+		// encoding/csv can't read custom terminators, assume the header isn't quoted.
+		return readHeaderLine(f, w.Comma, w.Terminator, comment)
+	}
+
+	r := csv.NewReader(f)
+	r.Comma = w.Comma
+	if comment != "" {
+		r.Comment, _ = utf8.DecodeRuneInString(comment)
+	}
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	return header, err
+}
+
+// readHeaderTranslations reads the translations of the locale from the JSON
+// file of --header-translations.
+func readHeaderTranslations(filename string, locale string) (json2csv.TranslationMap, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var translations json2csv.Translations
+	if err := json.Unmarshal(b, &translations); err != nil {
+		return nil, fmt.Errorf("Invalid --header-translations file %q: %s", filename, err)
+	}
+	m, ok := translations.Lookup(locale)
+	if !ok {
+		return nil, fmt.Errorf("No header translations for locale %q in %q", locale, filename)
+	}
+	return m, nil
+}
+
+func readHeaderLine(r io.Reader, comma rune, terminator string, comment string) ([]string, error) {
+	br := bufio.NewReader(r)
+	var line string
+	for {
+		line = ""
+		for !strings.HasSuffix(line, terminator) {
+			s, err := br.ReadString(terminator[len(terminator)-1])
+			line += s
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		if comment == "" || !strings.HasPrefix(line, comment) {
+			break
+		}
+	}
+
+	line = strings.TrimSuffix(line, terminator)
+	if line == "" {
+		return nil, nil
+	}
+	return strings.Split(line, string(comma)), nil
+}
+
+// columnCount returns the number of columns for the progress (-v).
+func columnCount(results []json2csv.KeyValue, c *cli.Context) int {
+	if logLevel < verboseLevel {
+		return 0
+	}
+	keys, _, err := newCSVWriter(ioutil.Discard, c).Header(results)
+	if err != nil {
+		return 0
+	}
+	return len(keys)
+}
+
+// writeCSVFile writes CSV to the file or the location of a registered sink,
+// and returns the size of the output.
+// If header is not nil, the columns keep its order and the other columns are
+// appended, see printCSV.
+func writeCSVFile(filename string, source string, results []json2csv.KeyValue, header []string, c *cli.Context) (int64, error) {
+	f, err := json2csv.CreateSink(filename)
+	if err != nil {
+		return 0, err
+	}
+
+	w := &countingWriter{w: interruptibleWriter{f}}
+	if len(results) > 0 {
+		if err := printCSV(w, source, results, header, c); err != nil {
+			f.Close()
+			if interrupted() {
+				return 0, finalizeInterrupted(filename, c.String("on-interrupt"))
+			}
+			if _, ok := err.(*json2csv.SelfCheckError); ok && json2csv.IsLocalLocation(filename) {
+				os.Remove(json2csv.LocalPath(filename))
+			}
+			return 0, err
+		}
+	}
+	return w.n, f.Close()
+}
+
+func writeMappingFile(filename string, results []json2csv.KeyValue, c *cli.Context) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	csv := newCSVWriter(f, c)
+	if err := csv.WriteHeaderMapping(f, results); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli"
+	"github.com/yukithm/json2csv/v2"
+)
+
+func TestOpenInputFileURL(t *testing.T) {
+	f, err := ioutil.TempFile("", "json2csv-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(`{"id": 1}`)
+	f.Close()
+
+	for _, mmap := range []bool{false, true} {
+		r, err := openInput("file://"+f.Name(), mmap)
+		if err != nil {
+			t.Fatalf("mmap=%v: %s", mmap, err)
+		}
+		data, _ := ioutil.ReadAll(r)
+		r.Close()
+		if string(data) != `{"id": 1}` {
+			t.Errorf("mmap=%v: Expected %q, but %q", mmap, `{"id": 1}`, data)
+		}
+	}
+}
+
+func TestReadMapped(t *testing.T) {
+	testCases := []struct {
+		in       string
+		expected interface{}
+	}{
+		{`{"id": 12345678901234567890}`, map[string]interface{}{"id": json.Number("12345678901234567890")}},
+		{"[{\"a\":1,\"b\":\"x\"}]\n[{\"a\":2}]", nil},
+		{"{\"a\":\"\xff\"}", nil},
+		{"  ", nil},
+		{`{"a":`, nil},
+	}
+
+	for caseIndex, testCase := range testCases {
+		f, err := ioutil.TempFile("", "json2csv-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(f.Name())
+		f.WriteString(testCase.in)
+		f.Close()
+
+		for _, decoder := range []string{"std", "jsoniter"} {
+			set := flag.NewFlagSet("test", flag.ContinueOnError)
+			set.String("decoder", decoder, "")
+			c := cli.NewContext(nil, set, nil)
+
+			r, err := openInput(f.Name(), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, ok := r.(*mappedReader)
+			if !ok {
+				t.Fatalf("%d: %s: Expected *mappedReader, but %T", caseIndex, decoder, r)
+			}
+			mapped, mappedErr := readMapped(m, c)
+			r.Close()
+
+			r, err = openInput(f.Name(), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			read, readErr := readJSON(r, c)
+			r.Close()
+
+			if (mappedErr == nil) != (readErr == nil) {
+				t.Errorf("%d: %s: Expected error %v, but %v with --mmap", caseIndex, decoder, readErr, mappedErr)
+			}
+			if !reflect.DeepEqual(mapped, read) {
+				t.Errorf("%d: %s: Expected %#v, but %#v with --mmap", caseIndex, decoder, read, mapped)
+			}
+			if testCase.expected != nil && !reflect.DeepEqual(mapped, testCase.expected) {
+				t.Errorf("%d: %s: Expected %#v, but %#v", caseIndex, decoder, testCase.expected, mapped)
+			}
+		}
+	}
+}
+
+// blockingReader blocks reading until it is closed.
+type blockingReader struct {
+	closed chan struct{}
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	<-r.closed
+	return 0, os.ErrClosed
+}
+
+func (r *blockingReader) Close() error {
+	close(r.closed)
+	return nil
+}
+
+func TestReadInputContextClosesOnTimeout(t *testing.T) {
+	r := &blockingReader{closed: make(chan struct{})}
+	json2csv.RegisterSource("hang", json2csv.SourceFunc(func(location string) (io.ReadCloser, error) {
+		return r, nil
+	}))
+
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.String("decoder", "std", "")
+	set.Bool("mmap", false, "")
+	c := cli.NewContext(cli.NewApp(), set, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := readInputContext(ctx, "hang://input", c); err != context.DeadlineExceeded {
+		t.Errorf("Expected %v, but %v", context.DeadlineExceeded, err)
+	}
+	select {
+	case <-r.closed:
+	case <-time.After(time.Second):
+		t.Errorf("Expected the input to be closed")
+	}
+}
+
+func TestCheckInputSize(t *testing.T) {
+	f, err := ioutil.TempFile("", "json2csv-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(`[{"id": 1}, {"id": 2}]`)
+	f.Close()
+
+	testCases := []struct {
+		maxMemory string
+		fails     bool
+	}{
+		{"", false},
+		{"1KB", false},
+		{"10B", true},
+	}
+	for caseIndex, testCase := range testCases {
+		set := flag.NewFlagSet("test", flag.ContinueOnError)
+		set.String("max-memory", testCase.maxMemory, "")
+		c := cli.NewContext(nil, set, nil)
+
+		err := checkInputSize(f.Name(), c)
+		if (err != nil) != testCase.fails {
+			t.Errorf("%d: Expected fails=%v, but %v", caseIndex, testCase.fails, err)
+		}
+	}
+}
+
+func TestInterruptedOutputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "json2csv-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	defer func(ctx context.Context) { interruptContext = ctx }(interruptContext)
+	interruptContext = canceled
+
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.String("format", "csv", "")
+	set.String("dialect", "csv", "")
+	set.String("on-interrupt", "remove", "")
+	set.String("socket", filepath.Join(dir, "out.sock"), "")
+	c := cli.NewContext(nil, set, nil)
+
+	output := filepath.Join(dir, "out.csv")
+	if _, err := writeCSVFile(output, "test", []json2csv.KeyValue{{"/id": 1}}, nil, c); err != errInterrupted {
+		t.Errorf("Expected %v, but %v", errInterrupted, err)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("Expected the output to be removed, but %v", err)
+	}
+
+	// Waiting for the client of the socket stops.
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := streamOutput(canceled, c)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != errInterrupted {
+			t.Errorf("Expected %v, but %v", errInterrupted, err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected streamOutput to return")
+	}
+}
+
+func TestDecodersAtEOF(t *testing.T) {
+	for _, name := range []string{"std", "jsoniter"} {
+		factory, _ := json2csv.LookupDecoder(name)
+		d := factory(strings.NewReader("{\"id\": 1}\n{\"id\": 2}\n  \n"))
+		for i := 0; i < 2; i++ {
+			if _, err := d.Decode(); err != nil {
+				t.Fatalf("%s: %d: %s", name, i, err)
+			}
+		}
+		if _, err := d.Decode(); err != io.EOF {
+			t.Errorf("%s: Expected %v, but %v", name, io.EOF, err)
+		}
+
+		d = factory(strings.NewReader("{\"id\": 1}]"))
+		d.Decode()
+		if _, err := d.Decode(); err == nil || err == io.EOF {
+			t.Errorf("%s: Expected a syntax error, but %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package main
+
+import "io/ioutil"
+
+// mappedFile is a fallback of the memory-mapped file, which just reads the
+// whole file on the platforms without mmap(2).
+type mappedFile struct {
+	data []byte
+}
+
+func openMappedFile(filename string) (*mappedFile, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &mappedFile{data: data}, nil
+}
+
+// Bytes returns the content of the file. It must not be used after Close.
+func (m *mappedFile) Bytes() []byte {
+	return m.data
+}
+
+func (m *mappedFile) Close() error {
+	m.data = nil
+	return nil
+}
@@ -0,0 +1,51 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// mappedFile is a read-only memory-mapped file.
+type mappedFile struct {
+	data []byte
+}
+
+func openMappedFile(filename string) (*mappedFile, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() == 0 {
+		// mmap(2) doesn't accept an empty mapping.
+		return &mappedFile{}, nil
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, &os.PathError{Op: "mmap", Path: filename, Err: err}
+	}
+	return &mappedFile{data: data}, nil
+}
+
+// Bytes returns the content of the file. It must not be used after Close.
+func (m *mappedFile) Bytes() []byte {
+	return m.data
+}
+
+func (m *mappedFile) Close() error {
+	if m.data == nil {
+		return nil
+	}
+	data := m.data
+	m.data = nil
+	return syscall.Munmap(data)
+}
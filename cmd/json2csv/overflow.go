@@ -0,0 +1,33 @@
+package main
+
+import (
+	"io"
+
+	"github.com/urfave/cli"
+	"github.com/yukithm/json2csv/v2"
+)
+
+// overflow receives the cell values exceeding --max-cell-size
+// (--overflow-file).
+var overflow io.WriteCloser
+
+// openOverflow creates the --overflow-file if it is specified.
+func openOverflow(c *cli.Context) error {
+	if c.String("overflow-file") == "" {
+		return nil
+	}
+	f, err := json2csv.CreateSink(c.String("overflow-file"))
+	if err != nil {
+		return err
+	}
+	overflow = f
+	return nil
+}
+
+// closeOverflow closes the --overflow-file if it is open.
+func closeOverflow() error {
+	if overflow == nil {
+		return nil
+	}
+	return overflow.Close()
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+	"github.com/yukithm/json2csv/v2"
+)
+
+// isOutputTemplate reports whether the output is a template like
+// "out/{{.Partition}}/{{.Date}}.csv".
+func isOutputTemplate(output string) bool {
+	return strings.Contains(output, "{{")
+}
+
+// partition is the rows which have the same value of --partition-by.
+type partition struct {
+	value string
+	rows  []json2csv.KeyValue
+}
+
+// splitPartitions splits the rows by the value of the key, keeping the order
+// of the first appearance. Rows without the value are in the "" partition.
+func splitPartitions(results []json2csv.KeyValue, key string) []*partition {
+	if key == "" {
+		return []*partition{{rows: results}}
+	}
+
+	var partitions []*partition
+	byValue := make(map[string]*partition)
+	for i, kv := range results {
+		var value string
+		if v, ok := kv[key]; ok {
+			value = fmt.Sprint(v)
+		} else {
+			logf(debugLevel, "row %d: no value of %s, written to the %s partition", i+1, key, emptyPartitionName)
+		}
+		p, ok := byValue[value]
+		if !ok {
+			p = &partition{value: value}
+			byValue[value] = p
+			partitions = append(partitions, p)
+		}
+		p.rows = append(p.rows, kv)
+	}
+	return partitions
+}
+
+// emptyPartitionName is the name of the partition of the rows without the
+// value, which would otherwise collapse the directory of the output.
+const emptyPartitionName = "_empty"
+
+// safePartitionName replaces characters which would escape the directory.
+func safePartitionName(value string) string {
+	if value == "" {
+		return emptyPartitionName
+	}
+	value = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', 0:
+			return '_'
+		}
+		return r
+	}, value)
+	if value == "." || value == ".." {
+		return "_"
+	}
+	return value
+}
+
+// dropEmptyColumns removes the columns which have no values in the rows
+// (--drop-empty-columns). Empty strings are values with --quote-empty.
+// It returns the rows without the keys of the dropped columns and the header
+// of the rest.
+func dropEmptyColumns(rows []json2csv.KeyValue, keys []string, header []string, quoteEmpty bool) ([]json2csv.KeyValue, []string) {
+	empty := make(map[string]bool)
+	var kept []string
+	for i, key := range keys {
+		found := false
+		for _, kv := range rows {
+			if v, ok := kv[key]; ok && key != "" && (quoteEmpty || fmt.Sprint(v) != "") {
+				found = true
+				break
+			}
+		}
+		if found {
+			kept = append(kept, header[i])
+		} else if key != "" {
+			empty[key] = true
+		}
+	}
+	if len(empty) == 0 {
+		return rows, kept
+	}
+
+	// Rows with empty strings of the dropped columns are copied without them.
+	newRows := make([]json2csv.KeyValue, len(rows))
+	for i, kv := range rows {
+		newRows[i] = kv
+		for key := range empty {
+			if _, ok := kv[key]; ok {
+				newRows[i] = make(json2csv.KeyValue, len(kv))
+				for k, v := range kv {
+					if !empty[k] {
+						newRows[i][k] = v
+					}
+				}
+				break
+			}
+		}
+	}
+	return newRows, kept
+}
+
+// writePartitions writes the rows into the outputs built from the output
+// template and the input file name for each partition (--partition-by). All
+// partitions have the same columns unless --drop-empty-columns.
+func writePartitions(results []json2csv.KeyValue, source string, input string, output string, c *cli.Context) error {
+	namer, err := newOutputNamer(output, time.Now())
+	if err != nil {
+		return err
+	}
+
+	keys, header, err := newCSVWriter(ioutil.Discard, c).Header(results)
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	var written []string
+	outputs := make(map[string]string)
+	for _, p := range splitPartitions(results, c.String("partition-by")) {
+		filename, err := namer.PartitionName(input, 1, safePartitionName(p.value))
+		if err != nil {
+			return err
+		}
+		if prev, ok := outputs[filename]; ok {
+			return fmt.Errorf("Partitions %q and %q have the same output %q", prev, p.value, filename)
+		}
+		outputs[filename] = p.value
+
+		if json2csv.IsLocalLocation(filename) {
+			if err := os.MkdirAll(filepath.Dir(json2csv.LocalPath(filename)), 0755); err != nil {
+				return err
+			}
+		}
+		rows, columns := p.rows, header
+		if c.Bool("drop-empty-columns") {
+			rows, columns = dropEmptyColumns(p.rows, keys, header, c.Bool("quote-empty"))
+		}
+		n, err := writeCSVFile(filename, source, rows, columns, c)
+		if err != nil {
+			return err
+		}
+		size += n
+		written = append(written, filename)
+		stats.Add(source, filename, len(rows), len(columns), n)
+	}
+
+	if err := checkOutput(len(results), size, c); err != nil {
+		for _, filename := range written {
+			if json2csv.IsLocalLocation(filename) {
+				os.Remove(json2csv.LocalPath(filename))
+			}
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestSafePartitionName(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  string
+	}{
+		{"jp", "jp"},
+		{"", "_empty"},
+		{"a/b\\c", "a_b_c"},
+		{"..", "_"},
+	}
+
+	for caseIndex, testCase := range testCases {
+		if got := safePartitionName(testCase.value); got != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, got)
+		}
+	}
+}
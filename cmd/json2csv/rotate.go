@@ -0,0 +1,344 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli"
+	"github.com/yukithm/json2csv/v2"
+)
+
+// rotatingWriter writes the rows into a new CSV file for each period of
+// --rotate. The rows are written as they arrive, so the header is fixed by
+// --columns, by the header of the existing file (local files are appended
+// to, e.g. when restarted within the period), or by the keys of the first
+// row. Keys which are not in the header are written to the sidecar file
+// FILE.dropped.jsonl. The file is synced and closed at the end of the period
+// even if no rows follow.
+type rotatingWriter struct {
+	period time.Duration
+	name   func(start time.Time) (string, error) // output file name of the period
+	source string
+	c      *cli.Context
+
+	mu       sync.Mutex
+	filename string
+	file     io.WriteCloser
+	out      *countingWriter
+	csv      *json2csv.StreamWriter
+	dropped  io.WriteCloser // sidecar of the dropped keys, opened on demand
+	rows     int
+	end      time.Time
+	timer    *time.Timer
+	err      error // error of closing at the end of the period
+}
+
+// droppedSuffix is the suffix of the sidecar file of the dropped keys.
+const droppedSuffix = ".dropped.jsonl"
+
+// droppedRecord is a line of the sidecar file of the dropped keys.
+type droppedRecord struct {
+	Row    int         `json:"row"`
+	Column string      `json:"column"`
+	Value  interface{} `json:"value"`
+}
+
+func newRotatingWriter(source string, name func(start time.Time) (string, error), c *cli.Context) *rotatingWriter {
+	return &rotatingWriter{
+		period: c.Duration("rotate"),
+		name:   name,
+		source: source,
+		c:      c,
+	}
+}
+
+// checkRotate validates --rotate and the output name template.
+func checkRotate(c *cli.Context) error {
+	period := c.Duration("rotate")
+	if period == 0 {
+		return nil
+	}
+	if period < time.Second {
+		return fmt.Errorf("Invalid --rotate value %s", period)
+	}
+	if c.Bool("transpose") {
+		return fmt.Errorf("--rotate can't be used with --transpose")
+	}
+
+	var tmpl string
+	switch {
+	case c.Bool("stream"):
+		if !isOutputTemplate(c.String("output")) {
+			return fmt.Errorf("--rotate requires --output template (e.g. out/{{.Date}}-{{.Time}}.csv) with --stream")
+		}
+		if c.String("socket") != "" {
+			return fmt.Errorf("--rotate can't be used with --socket")
+		}
+		tmpl = c.String("output")
+	case c.String("watch") != "":
+		tmpl = c.String("output-name")
+	default:
+		return fmt.Errorf("--rotate requires --stream or --watch")
+	}
+
+	namer, err := newOutputNamer(tmpl, time.Now())
+	if err != nil {
+		return err
+	}
+	return checkRotateName(func(start time.Time) (string, error) {
+		namer.now = start
+		return namer.Name("input.json", 1)
+	}, period)
+}
+
+// checkRotateName returns an error unless the output names of consecutive
+// periods differ, otherwise each period would overwrite the previous file.
+func checkRotateName(name func(start time.Time) (string, error), period time.Duration) error {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	a, err := name(start)
+	if err != nil {
+		return err
+	}
+	b, err := name(start.Add(period))
+	if err != nil {
+		return err
+	}
+	if a == b {
+		return fmt.Errorf("--rotate requires an output name which changes every period (e.g. {{.Date}}-{{.Time}}.csv)")
+	}
+	return nil
+}
+
+// WriteRecord writes the row into the file of the current period.
+func (r *rotatingWriter) WriteRecord(kv json2csv.KeyValue) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	return r.writeRecord(kv)
+}
+
+// WriteRecords writes the rows into the file of the current period without
+// interleaving them with other rows, and syncs the file, so that the rows are
+// durable when the input is recorded to the ledger of --watch.
+func (r *rotatingWriter) WriteRecords(rows []json2csv.KeyValue) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+
+	for _, kv := range rows {
+		if err := r.writeRecord(kv); err != nil {
+			return err
+		}
+	}
+	if r.csv == nil {
+		return nil
+	}
+	if err := syncFile(r.file); err != nil {
+		return err
+	}
+	return syncFile(r.dropped)
+}
+
+func (r *rotatingWriter) writeRecord(kv json2csv.KeyValue) error {
+	now := time.Now()
+	if r.csv != nil && !now.Before(r.end) {
+		if err := r.closeFile(); err != nil {
+			return err
+		}
+	}
+	if r.csv == nil {
+		if err := r.openFile(now); err != nil {
+			return err
+		}
+	}
+	if err := r.csv.WriteRecord(kv); err != nil {
+		return err
+	}
+	r.rows++
+	return nil
+}
+
+// Keys returns the keys of the header of the current file.
+func (r *rotatingWriter) Keys() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.csv == nil {
+		return nil
+	}
+	return r.csv.Keys()
+}
+
+// Current returns the name of the file of the current period, or "".
+func (r *rotatingWriter) Current() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.filename
+}
+
+// Close writes and closes the file of the current period.
+func (r *rotatingWriter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+	return r.err
+}
+
+// periodStart returns the start of the period containing now, aligned to the
+// local time, e.g. the local midnight for 24h.
+func periodStart(now time.Time, period time.Duration) time.Time {
+	_, offset := now.Zone()
+	shift := time.Duration(offset) * time.Second
+	return now.Add(shift).Truncate(period).Add(-shift)
+}
+
+// openFile starts the period containing now.
+func (r *rotatingWriter) openFile(now time.Time) error {
+	start := periodStart(now, r.period)
+	filename, err := r.name(start)
+	if err != nil {
+		return err
+	}
+
+	f, header, err := r.createFile(filename)
+	if err != nil {
+		return err
+	}
+	out := &countingWriter{w: f}
+	csv := json2csv.NewStreamWriter(out)
+	configureCSVWriter(csv.CSVWriter, r.c)
+	if r.c.String("columns") != "" {
+		csv.Columns = strings.Split(r.c.String("columns"), ",")
+	}
+	csv.AppendHeader = header
+	if err := setComments(csv.CSVWriter, r.source, 0, r.c); err != nil {
+		f.Close()
+		return err
+	}
+	csv.Dropped = r.writeDropped
+
+	r.filename, r.file, r.out, r.csv, r.rows = filename, f, out, csv, 0
+	r.end = start.Add(r.period)
+	r.timer = time.AfterFunc(r.end.Sub(now), func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.csv == csv {
+			if err := r.closeFile(); err != nil && r.err == nil {
+				r.err = err
+			}
+		}
+	})
+	return nil
+}
+
+// writeDropped writes the keys of the row which are not in the header to the
+// sidecar file of the current file, one JSON line per key.
+func (r *rotatingWriter) writeDropped(row int, dropped json2csv.KeyValue) error {
+	if r.dropped == nil {
+		sidecar := r.filename + droppedSuffix
+		f, _, err := openAppend(sidecar)
+		if err != nil {
+			return err
+		}
+		r.dropped = f
+		logf(normalLevel, "%s: keys not in the header are written to %s", r.filename, sidecar)
+	}
+
+	for _, key := range dropped.SortedKeys() {
+		line, err := json.Marshal(droppedRecord{row, key, dropped[key]})
+		if err != nil {
+			return err
+		}
+		if _, err := r.dropped.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeFile syncs and closes the file of the current period, and its
+// sidecar file.
+func (r *rotatingWriter) closeFile() error {
+	if r.csv == nil {
+		return nil
+	}
+	r.timer.Stop()
+	f, dropped := r.file, r.dropped
+	stats.Add(r.source, r.filename, r.rows, len(r.csv.Keys()), r.out.n)
+	r.filename, r.file, r.out, r.csv, r.dropped = "", nil, nil, nil, nil
+
+	err := closeSynced(f)
+	if dropped != nil {
+		if derr := closeSynced(dropped); err == nil {
+			err = derr
+		}
+	}
+	return err
+}
+
+// closeSynced syncs and closes the file.
+func closeSynced(f io.WriteCloser) error {
+	if err := syncFile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncFile commits the file to the storage if it is a local file.
+func syncFile(f io.Writer) error {
+	if s, ok := f.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// createFile opens the file of the period. A local file is appended to, and
+// the header is returned if it isn't empty.
+func (r *rotatingWriter) createFile(filename string) (io.WriteCloser, []string, error) {
+	f, exists, err := openAppend(filename)
+	if err != nil || !exists {
+		return f, nil, err
+	}
+	header, err := readCSVHeader(json2csv.LocalPath(filename), r.c)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, header, nil
+}
+
+// openAppend opens the file for appending if it is local, and returns
+// whether it has data already. Other locations are created.
+func openAppend(filename string) (io.WriteCloser, bool, error) {
+	if !json2csv.IsLocalLocation(filename) {
+		f, err := json2csv.CreateSink(filename)
+		return f, false, err
+	}
+
+	path := json2csv.LocalPath(filename)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, false, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, false, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, false, err
+	}
+	return f, fi.Size() > 0, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli"
+	"github.com/yukithm/json2csv/v2"
+)
+
+func TestPeriodStart(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	testCases := []struct {
+		now    time.Time
+		period time.Duration
+		want   time.Time
+	}{
+		{time.Date(2024, 5, 1, 13, 45, 0, 0, tokyo), time.Hour, time.Date(2024, 5, 1, 13, 0, 0, 0, tokyo)},
+		{time.Date(2024, 5, 1, 8, 30, 0, 0, tokyo), 24 * time.Hour, time.Date(2024, 5, 1, 0, 0, 0, 0, tokyo)},
+		{time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC), 24 * time.Hour, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for caseIndex, testCase := range testCases {
+		if got := periodStart(testCase.now, testCase.period); !got.Equal(testCase.want) {
+			t.Errorf("%d: Expected %s, but %s", caseIndex, testCase.want, got)
+		}
+	}
+}
+
+func TestRotatingWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "json2csv-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "out.csv")
+	if err := ioutil.WriteFile(filename, []byte("/name,/id\nfoo,1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.String("dialect", "csv", "")
+	set.Duration("rotate", time.Hour, "")
+	c := cli.NewContext(nil, set, nil)
+	r := newRotatingWriter("test", func(time.Time) (string, error) {
+		return filename, nil
+	}, c)
+
+	if err := r.WriteRecord(json2csv.KeyValue{"/id": 2, "/extra": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	// The row is in the file before the end of the period.
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/name,/id\nfoo,1\n,2\n"; string(b) != want {
+		t.Errorf("Expected %q, but %q", want, string(b))
+	}
+
+	if err := r.WriteRecords([]json2csv.KeyValue{{"/name": "bar", "/id": 3}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b, err = ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/name,/id\nfoo,1\n,2\nbar,3\n"; string(b) != want {
+		t.Errorf("Expected %q, but %q", want, string(b))
+	}
+	b, err = ioutil.ReadFile(filename + droppedSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"row":1,"column":"/extra","value":"x"}` + "\n"; string(b) != want {
+		t.Errorf("Expected %q, but %q", want, string(b))
+	}
+}
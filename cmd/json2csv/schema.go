@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/urfave/cli"
+	"github.com/yukithm/json2csv/v2"
+)
+
+// checkGoldenSchema compares the keys (JSON Pointers) of the results with the
+// golden schema (--golden-schema), or updates it (--update-golden-schema).
+func checkGoldenSchema(results []json2csv.KeyValue, c *cli.Context) error {
+	filename := c.String("golden-schema")
+	if filename == "" {
+		return nil
+	}
+
+	keys, _, err := newCSVWriter(ioutil.Discard, c).Header(results)
+	if err != nil {
+		return err
+	}
+	keys = nonEmpty(keys)
+
+	if c.Bool("update-golden-schema") {
+		return writeSchemaFile(filename, keys)
+	}
+
+	golden, err := readSchemaFile(filename)
+	if err != nil {
+		return err
+	}
+	if diff := diffSchema(golden, keys); len(diff) > 0 {
+		return fmt.Errorf("Schema drifted from %s:\n%s", filename, strings.Join(diff, "\n"))
+	}
+	return nil
+}
+
+// diffSchema returns the readable differences of the keys: added ("+"),
+// removed ("-") and renamed ("~", which has the same last token).
+func diffSchema(golden []string, keys []string) []string {
+	d := json2csv.CompareHeaders(golden, keys)
+
+	var diff []string
+	renamed := make(map[string]bool)
+	for _, r := range d.Removed {
+		for _, a := range d.Added {
+			if !renamed[a] && path.Base(a) == path.Base(r) {
+				renamed[a] = true
+				renamed[r] = true
+				diff = append(diff, fmt.Sprintf("~ %s -> %s", r, a))
+				break
+			}
+		}
+	}
+	for _, key := range d.Added {
+		if !renamed[key] {
+			diff = append(diff, "+ "+key)
+		}
+	}
+	for _, key := range d.Removed {
+		if !renamed[key] {
+			diff = append(diff, "- "+key)
+		}
+	}
+	return diff
+}
+
+// readSchemaFile reads the keys (one JSON Pointer per line).
+func readSchemaFile(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var keys []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if s.Text() != "" {
+			keys = append(keys, s.Text())
+		}
+	}
+	return keys, s.Err()
+}
+
+// writeSchemaFile writes the keys (one JSON Pointer per line).
+func writeSchemaFile(filename string, keys []string) error {
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key)
+		b.WriteByte('\n')
+	}
+	return ioutil.WriteFile(filename, []byte(b.String()), 0644)
+}
+
+// nonEmpty returns the keys except empty ones (previous columns which no
+// longer exist).
+func nonEmpty(keys []string) []string {
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key != "" {
+			result = append(result, key)
+		}
+	}
+	return result
+}
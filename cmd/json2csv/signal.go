@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// errInterrupted is returned when the conversion is stopped by a signal.
+var errInterrupted = errors.New("Interrupted")
+
+var (
+	// interruptContext is done when SIGINT or SIGTERM is received.
+	interruptContext = context.Background()
+
+	interruptMu     sync.Mutex
+	interruptSignal os.Signal
+)
+
+// handleSignals stops the conversions gracefully on SIGINT or SIGTERM:
+// the output being written is finalized and no more inputs are read.
+// The second signal exits immediately.
+func handleSignals() {
+	ctx, cancel := context.WithCancel(context.Background())
+	interruptContext = ctx
+
+	ch := make(chan os.Signal, 2)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-ch
+		interruptMu.Lock()
+		interruptSignal = sig
+		interruptMu.Unlock()
+		cancel()
+
+		sig = <-ch
+		os.Exit(signalExitCode(sig))
+	}()
+}
+
+// interrupted reports whether a signal has been received.
+func interrupted() bool {
+	return interruptContext.Err() != nil
+}
+
+// interruptibleWriter fails the writes after a signal, so that writing a large
+// output stops when interrupted.
+type interruptibleWriter struct {
+	w io.Writer
+}
+
+func (w interruptibleWriter) Write(p []byte) (int, error) {
+	if interrupted() {
+		return 0, errInterrupted
+	}
+	return w.w.Write(p)
+}
+
+// exitInterrupted prints the stats and exits with 128 + the signal number.
+func exitInterrupted() {
+	interruptMu.Lock()
+	sig := interruptSignal
+	interruptMu.Unlock()
+
+	logf(normalLevel, "Interrupted by %s", sig)
+	stats.Print()
+	os.Exit(signalExitCode(sig))
+}
+
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
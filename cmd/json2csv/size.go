@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var sizeUnits = []struct {
+	suffix string
+	scale  int64
+}{
+	// longer suffixes first
+	{"KiB", 1 << 10},
+	{"MiB", 1 << 20},
+	{"GiB", 1 << 30},
+	{"TiB", 1 << 40},
+	{"KB", 1000},
+	{"MB", 1000 * 1000},
+	{"GB", 1000 * 1000 * 1000},
+	{"TB", 1000 * 1000 * 1000 * 1000},
+	{"K", 1 << 10},
+	{"M", 1 << 20},
+	{"G", 1 << 30},
+	{"T", 1 << 40},
+	{"B", 1},
+}
+
+// parseSize parses a size string like "512MB", "1GiB" or "1024".
+func parseSize(s string) (int64, error) {
+	str := strings.TrimSpace(s)
+	scale := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(strings.ToUpper(str), strings.ToUpper(unit.suffix)) {
+			str = strings.TrimSpace(str[:len(str)-len(unit.suffix)])
+			scale = unit.scale
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(str, 64)
+	// NaN fails every comparison, and the conversion of larger values to
+	// int64 is undefined.
+	if err != nil || !(n >= 0 && n < float64(math.MaxInt64/scale)) {
+		return 0, fmt.Errorf("Invalid size %q", s)
+	}
+	return int64(n * float64(scale)), nil
+}
+
+// formatSize formats n bytes in decimal units like "1.5 MB".
+func formatSize(n int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	f := float64(n)
+	i := 0
+	for ; f >= 1000 && i < len(units)-1; i++ {
+		f /= 1000
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f %s", f, units[i])
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	testCases := []struct {
+		in       string
+		expected int64
+		err      string
+	}{
+		{"1024", 1024, ``},
+		{"512MB", 512 * 1000 * 1000, ``},
+		{"1.5 KiB", 1536, ``},
+		{"2g", 2 << 30, ``},
+		{"0", 0, ``},
+		{"-1", 0, `Invalid size "-1"`},
+		{"abc", 0, `Invalid size "abc"`},
+		{"NaN", 0, `Invalid size "NaN"`},
+		{"Inf", 0, `Invalid size "Inf"`},
+		{"+Inf", 0, `Invalid size "+Inf"`},
+		{"1e30", 0, `Invalid size "1e30"`},
+		{"10000000TB", 0, `Invalid size "10000000TB"`},
+	}
+
+	for caseIndex, testCase := range testCases {
+		actual, err := parseSize(testCase.in)
+		if err != nil {
+			if err.Error() != testCase.err {
+				t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.err, err)
+			}
+		} else if testCase.err != "" {
+			t.Errorf("%d: Expected %q, but no error", caseIndex, testCase.err)
+		} else if actual != testCase.expected {
+			t.Errorf("%d: Expected %d, but %d", caseIndex, testCase.expected, actual)
+		}
+	}
+}
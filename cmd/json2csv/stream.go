@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+	"github.com/yukithm/json2csv/v2"
+	"github.com/yukithm/json2csv/v2/jsonpointer"
+)
+
+// streamAction converts a sequence of JSON values (e.g. NDJSON) and writes
+// each row as soon as it is converted.
+func streamAction(c *cli.Context) error {
+	input := "-"
+	if c.NArg() > 1 {
+		return fmt.Errorf("--stream accepts only one input")
+	} else if c.NArg() == 1 {
+		input = c.Args()[0]
+	}
+
+	var r io.Reader = os.Stdin
+	if input != "-" {
+		f, err := openInput(input, c.Bool("mmap"))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	ctx := interruptContext
+	if c.Duration("timeout") > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Duration("timeout"))
+		defer cancel()
+	}
+
+	if c.Duration("rotate") > 0 {
+		return streamRotating(ctx, input, r, c)
+	}
+
+	out, closeOutput, err := streamOutput(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	w := &countingWriter{w: out}
+	csv, finish, err := newStreamWriter(w, sourceName(input), c)
+	if err != nil {
+		closeOutput()
+		return err
+	}
+
+	rows, err := streamJSON(ctx, newDecoder(r, c), csv, c.String("path"), rowOptions(c))
+	if err == context.DeadlineExceeded {
+		err = timeoutError(c)
+	}
+	if err != nil && err != errInterrupted {
+		finish()
+		closeOutput()
+		return err
+	}
+
+	// All rows written so far are complete, since each row is flushed (or
+	// the transposed rows are written by finish), even if interrupted.
+	ferr := finish()
+	if cerr := closeOutput(); ferr == nil {
+		ferr = cerr
+	}
+	if ferr != nil {
+		return ferr
+	}
+	output := c.String("output")
+	if c.String("socket") != "" {
+		output = c.String("socket")
+	}
+	stats.Add(sourceName(input), output, rows, len(csv.Keys()), w.n)
+	if err == errInterrupted {
+		return finalizeInterrupted(c.String("output"), c.String("on-interrupt"))
+	}
+	return nil
+}
+
+// newStreamWriter returns the writer of the stream and the function which
+// completes the output. With --transpose, the rows are spooled to a temporary
+// file and written by the function.
+func newStreamWriter(w io.Writer, source string, c *cli.Context) (recordWriter, func() error, error) {
+	var columns []string
+	if c.String("columns") != "" {
+		columns = strings.Split(c.String("columns"), ",")
+	}
+
+	if c.Bool("transpose") {
+		csv := json2csv.NewTransposeWriter(w)
+		configureCSVWriter(csv.CSVWriter, c)
+		if err := setComments(csv.CSVWriter, source, 0, c); err != nil {
+			return nil, nil, err
+		}
+		csv.Columns = columns
+		return csv, csv.Close, nil
+	}
+
+	csv := json2csv.NewStreamWriter(w)
+	configureCSVWriter(csv.CSVWriter, c)
+	if err := setComments(csv.CSVWriter, source, 0, c); err != nil {
+		return nil, nil, err
+	}
+	csv.Columns = columns
+	csv.Dropped = warnDroppedKeys
+	return csv, func() error { return nil }, nil
+}
+
+// streamRotating converts the stream into a new output file for each period
+// of --rotate. The output is a template whose Date and Time fields are the
+// start of the period.
+func streamRotating(ctx context.Context, input string, r io.Reader, c *cli.Context) error {
+	namer, err := newOutputNamer(c.String("output"), time.Now())
+	if err != nil {
+		return err
+	}
+	rotation := newRotatingWriter(sourceName(input), func(start time.Time) (string, error) {
+		namer.now = start
+		return namer.Name(input, 1)
+	}, c)
+
+	_, err = streamJSON(ctx, newDecoder(r, c), rotation, c.String("path"), rowOptions(c))
+	if err == context.DeadlineExceeded {
+		err = timeoutError(c)
+	}
+	if err == errInterrupted {
+		output := rotation.Current()
+		if err := rotation.Close(); err != nil {
+			return err
+		}
+		return finalizeInterrupted(output, c.String("on-interrupt"))
+	} else if err != nil {
+		rotation.Close()
+		return err
+	}
+	return rotation.Close()
+}
+
+// finalizeInterrupted applies the --on-interrupt policy to the output file
+// of the interrupted stream.
+func finalizeInterrupted(output string, policy string) error {
+	if output == "" || !json2csv.IsLocalLocation(output) {
+		return errInterrupted
+	}
+	output = json2csv.LocalPath(output)
+
+	switch policy {
+	case "partial":
+		if err := os.Rename(output, output+".partial"); err != nil {
+			return err
+		}
+	case "remove":
+		if err := os.Remove(output); err != nil {
+			return err
+		}
+	}
+	return errInterrupted
+}
+
+// streamOutput returns the destination of the stream: the UNIX socket
+// (--socket), the output file (--output) or STDOUT.
+// Waiting for the client of the socket stops when ctx is done.
+func streamOutput(ctx context.Context, c *cli.Context) (io.Writer, func() error, error) {
+	if socket := c.String("socket"); socket != "" {
+		if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+			return nil, nil, err
+		}
+		lis, err := net.Listen("unix", socket)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Serve the first client only. Accept can't be canceled, so the
+		// listener is closed when ctx is done.
+		accepted := make(chan struct{})
+		go func() {
+			select {
+			case <-ctx.Done():
+				lis.Close()
+			case <-accepted:
+			}
+		}()
+		conn, err := lis.Accept()
+		close(accepted)
+		lis.Close()
+		if ctx.Err() == context.DeadlineExceeded {
+			err = timeoutError(c)
+		} else if ctx.Err() != nil {
+			err = errInterrupted
+		}
+		if err != nil {
+			if conn != nil {
+				conn.Close()
+			}
+			return nil, nil, err
+		}
+		return conn, func() error {
+			os.Remove(socket)
+			return conn.Close()
+		}, nil
+	}
+
+	if output := c.String("output"); output != "" {
+		f, err := json2csv.CreateSink(output)
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, f.Close, nil
+	}
+
+	return os.Stdout, func() error { return nil }, nil
+}
+
+// rowOptions returns the conversion options of each value in the stream.
+// --max-memory doesn't apply since the rows are not held.
+func rowOptions(c *cli.Context) json2csv.Options {
+	opts := conversionOptions(c)
+	opts.MaxMemory = 0
+	return opts
+}
+
+// decoded is a value read by the decoder.
+type decoded struct {
+	data interface{}
+	err  error
+}
+
+// decodeAll reads the values in the background until an error (including
+// io.EOF) or ctx is done.
+func decodeAll(ctx context.Context, decoder json2csv.Decoder) <-chan decoded {
+	values := make(chan decoded)
+	go func() {
+		for {
+			data, err := decoder.Decode()
+			select {
+			case values <- decoded{data, err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return values
+}
+
+// recordWriter writes the rows of the stream.
+type recordWriter interface {
+	WriteRecord(kv json2csv.KeyValue) error
+	Keys() []string
+}
+
+// streamJSON converts the JSON values and returns the number of rows.
+// It stops between records when ctx is done, with errInterrupted if
+// interrupted by a signal, or context.DeadlineExceeded (--timeout).
+func streamJSON(ctx context.Context, decoder json2csv.Decoder, w recordWriter, path string, opts json2csv.Options) (int, error) {
+	conv, err := json2csv.NewConverter(opts)
+	if err != nil {
+		return 0, err
+	}
+	rows := 0
+	values := decodeAll(ctx, decoder)
+	for {
+		var v decoded
+		select {
+		case v = <-values:
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return rows, ctx.Err()
+			}
+			return rows, errInterrupted
+		}
+		data, err := v.data, v.err
+		if err == io.EOF {
+			return rows, nil
+		} else if err != nil {
+			return rows, err
+		}
+
+		if path != "" {
+			data, err = jsonpointer.Get(data, path)
+			if err != nil {
+				return rows, err
+			}
+		}
+
+		results, err := conv.Flatten(context.Background(), data)
+		if err != nil {
+			return rows, err
+		}
+		for _, result := range results {
+			if err := w.WriteRecord(result); err != nil {
+				return rows, err
+			}
+			rows++
+		}
+	}
+}
+
+// warnDroppedKeys prints the keys of the row which are not in the header.
+func warnDroppedKeys(row int, dropped json2csv.KeyValue) error {
+	logf(normalLevel, "row %d: dropped keys not in the header: %s", row, strings.Join(dropped.SortedKeys(), ", "))
+	return nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+// watcher watches a directory and converts each new JSON file to CSV.
+type watcher struct {
+	dir         string
+	outputDir   string
+	interval    time.Duration
+	concurrency int
+	namer       *outputNamer
+	convert     func(input, output string) error
+	rotating    bool // outputs are not per input (--rotate)
+
+	mu       sync.Mutex
+	ledger   *checksumCache
+	seen     map[string]os.FileInfo
+	inFlight map[string]bool
+	sem      chan struct{}
+}
+
+func watchAction(c *cli.Context) error {
+	if c.String("output-dir") == "" {
+		return fmt.Errorf("--watch requires --output-dir")
+	}
+	if c.Int("concurrency") < 1 {
+		return fmt.Errorf("Invalid --concurrency value %d", c.Int("concurrency"))
+	}
+	if c.Duration("interval") <= 0 {
+		return fmt.Errorf("Invalid --interval value %s", c.Duration("interval"))
+	}
+
+	ledger := &checksumCache{options: conversionHash(c), Entries: map[string]cacheEntry{}}
+	if c.String("ledger") != "" {
+		var err error
+		ledger, err = loadChecksumCache(c.String("ledger"), conversionHash(c))
+		if err != nil {
+			return err
+		}
+	}
+
+	namer, err := newOutputNamer(c.String("output-name"), time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.String("output-dir"), 0755); err != nil {
+		return err
+	}
+
+	w := &watcher{
+		dir:         c.String("watch"),
+		outputDir:   c.String("output-dir"),
+		interval:    c.Duration("interval"),
+		concurrency: c.Int("concurrency"),
+		namer:       namer,
+		convert: func(input, output string) error {
+			return convert(input, output, c)
+		},
+		ledger: ledger,
+	}
+	if c.Duration("rotate") > 0 {
+		// Append the rows of all inputs to the output of the period.
+		rotation := newRotatingWriter(c.String("watch"), func(start time.Time) (string, error) {
+			namer.now = start
+			name, err := namer.Name(c.String("watch"), 0)
+			return filepath.Join(c.String("output-dir"), name), err
+		}, c)
+		w.rotating = true
+		w.convert = func(input, _ string) error {
+			results, err := readResults(input, c)
+			if err != nil {
+				return err
+			}
+			return rotation.WriteRecords(results)
+		}
+		err := w.Run()
+		output := rotation.Current()
+		if cerr := rotation.Close(); err == errInterrupted && cerr != nil {
+			return cerr
+		}
+		if err == errInterrupted {
+			return finalizeInterrupted(output, c.String("on-interrupt"))
+		}
+		return err
+	}
+	return w.Run()
+}
+
+// Run polls the directory until interrupted by a signal.
+// It waits for the conversions in progress before returning.
+func (w *watcher) Run() error {
+	w.seen = map[string]os.FileInfo{}
+	w.inFlight = map[string]bool{}
+	w.sem = make(chan struct{}, w.concurrency)
+
+	for {
+		if err := w.poll(); err != nil {
+			return err
+		}
+		select {
+		case <-interruptContext.Done():
+			for i := 0; i < w.concurrency; i++ {
+				w.sem <- struct{}{}
+			}
+			return errInterrupted
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+func (w *watcher) poll() error {
+	files, err := filepath.Glob(filepath.Join(w.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if interrupted() {
+			return nil
+		}
+		info, err := os.Stat(file)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		// Wait until the file stops changing, it may still be being written.
+		prev, ok := w.seen[file]
+		w.seen[file] = info
+		if !ok || prev.Size() != info.Size() || !prev.ModTime().Equal(info.ModTime()) {
+			continue
+		}
+
+		w.mu.Lock()
+		busy := w.inFlight[file]
+		entry, same := w.ledger.Stat(file, info)
+		w.mu.Unlock()
+		if busy {
+			continue
+		}
+		if same && entry.Failed {
+			// Retried when the file changes.
+			continue
+		}
+
+		// Hash the file only if its size or modification time has changed.
+		checksum := entry.Checksum
+		if !same {
+			checksum, err = fileChecksum(file)
+			if err != nil {
+				log.Printf("%s: %s", file, err)
+				continue
+			}
+		}
+		var output string
+		if !w.rotating {
+			output, err = w.outputPath(file)
+			if err != nil {
+				log.Printf("%s: %s", file, err)
+				continue
+			}
+		}
+
+		w.mu.Lock()
+		unchanged := w.ledger.Unchanged(file, output, checksum)
+		if !unchanged {
+			w.inFlight[file] = true
+		}
+		w.mu.Unlock()
+		if unchanged {
+			continue
+		}
+
+		w.sem <- struct{}{}
+		go w.process(file, output, checksum, info)
+	}
+	return nil
+}
+
+func (w *watcher) process(input, output, checksum string, info os.FileInfo) {
+	defer func() {
+		w.mu.Lock()
+		delete(w.inFlight, input)
+		w.mu.Unlock()
+		<-w.sem
+	}()
+
+	err := w.convert(input, output)
+	if err != nil {
+		log.Printf("%s: %s", input, err)
+		if interrupted() {
+			// Retried in the next run.
+			return
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		w.ledger.Fail(input, checksum, info)
+	} else {
+		w.ledger.Update(input, output, checksum, info)
+	}
+	if w.ledger.filename != "" {
+		if err := w.ledger.Save(); err != nil {
+			log.Printf("%s: %s", w.ledger.filename, err)
+		}
+	}
+}
+
+func (w *watcher) outputPath(input string) (string, error) {
+	// The date of the output name is the date of the conversion.
+	w.namer.now = time.Now()
+	name, err := w.namer.Name(input, 0)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(w.outputDir, name), nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatcherRetriesFailedFilesOnlyWhenChanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "json2csv-watch-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "a.json")
+	if err := ioutil.WriteFile(file, []byte(`{`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	namer, err := newOutputNamer("{{.Stem}}.csv", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls int32
+	w := &watcher{
+		dir:       dir,
+		outputDir: dir,
+		namer:     namer,
+		convert: func(input, output string) error {
+			atomic.AddInt32(&calls, 1)
+			return errors.New("broken")
+		},
+		ledger:   &checksumCache{Entries: map[string]cacheEntry{}},
+		seen:     map[string]os.FileInfo{},
+		inFlight: map[string]bool{},
+		sem:      make(chan struct{}, 1),
+	}
+	// poll twice, since a file is converted after it stops changing
+	poll := func() {
+		for i := 0; i < 2; i++ {
+			if err := w.poll(); err != nil {
+				t.Fatal(err)
+			}
+			// wait for the conversion
+			w.sem <- struct{}{}
+			<-w.sem
+		}
+	}
+
+	poll()
+	poll()
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected 1 conversion of the unchanged file, but %d", n)
+	}
+	if entry := w.ledger.Entries[cacheKey(file)]; !entry.Failed {
+		t.Errorf("Expected the failure in the ledger, but %+v", entry)
+	}
+
+	mtime := time.Now().Add(time.Minute)
+	if err := os.Chtimes(file, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	poll()
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("Expected 2 conversions after the change, but %d", n)
+	}
+}
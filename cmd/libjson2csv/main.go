@@ -0,0 +1,95 @@
+// Command libjson2csv builds the converter as a C shared library.
+//
+//	go build -buildmode=c-shared -o libjson2csv.so ./cmd/libjson2csv
+//
+// The generated libjson2csv.h declares:
+//
+//	int json2csv_convert(const char *input, size_t input_len,
+//	                     const json2csv_options *opts,
+//	                     char **output, size_t *output_len, char **error);
+//	void json2csv_free(void *ptr);
+//
+// json2csv_convert returns 0 on success and stores the CSV into *output.
+// On failure it returns non-zero and stores the error message (NUL-terminated)
+// into *error. Both must be released by json2csv_free.
+// input_len must not exceed INT_MAX.
+package main
+
+/*
+#include <stdlib.h>
+
+typedef struct {
+	// 0: jsonpointer, 1: slash, 2: dot, 3: dot-bracket
+	int header_style;
+	// non-zero to transpose rows and columns
+	int transpose;
+	// JSON Pointer of the content, or NULL
+	const char *path;
+} json2csv_options;
+*/
+import "C"
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"unsafe"
+
+	"github.com/yukithm/json2csv/v2"
+)
+
+func main() {}
+
+//export json2csv_convert
+func json2csv_convert(input *C.char, inputLen C.size_t, opts *C.json2csv_options, output **C.char, outputLen *C.size_t, errmsg **C.char) C.int {
+	*output = nil
+	*outputLen = 0
+	*errmsg = nil
+
+	// C.GoBytes takes the length as C.int.
+	if uint64(inputLen) > math.MaxInt32 {
+		*errmsg = C.CString(fmt.Sprintf("input_len %d exceeds the limit %d", uint64(inputLen), math.MaxInt32))
+		return 1
+	}
+
+	var o json2csv.Options
+	if opts != nil {
+		if opts.header_style < 0 || opts.header_style > C.int(json2csv.DotBracketStyle) {
+			*errmsg = C.CString(fmt.Sprintf("Invalid header_style value %d", int(opts.header_style)))
+			return 1
+		}
+		o.Writer.HeaderStyle = json2csv.KeyStyle(opts.header_style)
+		o.Writer.Transpose = opts.transpose != 0
+		if opts.path != nil {
+			o.Path = C.GoString(opts.path)
+		}
+	}
+
+	csv, err := convert(C.GoBytes(unsafe.Pointer(input), C.int(inputLen)), o)
+	if err != nil {
+		*errmsg = C.CString(err.Error())
+		return 1
+	}
+
+	*output = (*C.char)(C.CBytes(csv))
+	*outputLen = C.size_t(len(csv))
+	return 0
+}
+
+//export json2csv_free
+func json2csv_free(ptr unsafe.Pointer) {
+	C.free(ptr)
+}
+
+func convert(input []byte, opts json2csv.Options) ([]byte, error) {
+	conv, err := json2csv.NewConverter(opts)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err := conv.ConvertReader(context.Background(), &b, bytes.NewReader(input)); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,59 @@
+package json2csv
+
+import (
+	"context"
+	"io"
+)
+
+// Converter converts JSON to CSV repeatedly with the same settings.
+//
+// It keeps the parsed JSON Pointers of the header across conversions, so
+// services converting many small payloads don't pay the setup costs on every
+// call. Call Reset to switch the output between inputs.
+//
+// A Converter is not safe for concurrent use.
+type Converter struct {
+	// Options are the options of the conversion.
+	Options Options
+
+	// Writer writes CSV. Its settings (HeaderStyle, Transpose, ...) are used
+	// for all conversions.
+	Writer *CSVWriter
+}
+
+// NewConverter returns new Converter which writes CSV to w.
+func NewConverter(w io.Writer, opts Options) *Converter {
+	writer := NewCSVWriter(w)
+	writer.CachePointers()
+	return &Converter{
+		Options: opts,
+		Writer:  writer,
+	}
+}
+
+// Convert converts JSON and writes CSV.
+// Nothing is written if there are no rows.
+func (c *Converter) Convert(data interface{}) error {
+	return c.ConvertContext(context.Background(), data)
+}
+
+// ConvertContext converts JSON and writes CSV.
+// The conversion is aborted with ctx.Err() when ctx is done, and nothing is
+// written then.
+func (c *Converter) ConvertContext(ctx context.Context, data interface{}) error {
+	results, err := JSON2CSVContext(ctx, data, c.Options)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return nil
+	}
+
+	return c.Writer.WriteCSV(results)
+}
+
+// Reset switches the output to w for the next conversion.
+// The settings of the Writer and the caches are kept.
+func (c *Converter) Reset(w io.Writer) {
+	c.Writer.Reset(w)
+}
@@ -0,0 +1,38 @@
+package json2csv_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yukithm/json2csv"
+)
+
+func TestConverter(t *testing.T) {
+	b1 := &bytes.Buffer{}
+	c := json2csv.NewConverter(b1, json2csv.Options{})
+	c.Writer.HeaderStyle = json2csv.DotNotationStyle
+	c.Writer.Comma = ';'
+
+	inputs := []interface{}{
+		[]map[string]interface{}{
+			{"id": 1, "user": map[string]interface{}{"name": "foo"}},
+		},
+		map[string]interface{}{"id": 2, "tags": []interface{}{"a", "b"}},
+	}
+
+	if err := c.Convert(inputs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b1.String(), "id;user.name\n1;foo\n"; got != want {
+		t.Errorf("Expected %q, but %q", want, got)
+	}
+
+	b2 := &bytes.Buffer{}
+	c.Reset(b2)
+	if err := c.Convert(inputs[1]); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b2.String(), "id;tags.0;tags.1\n2;a;b\n"; got != want {
+		t.Errorf("Expected %q, but %q", want, got)
+	}
+}
@@ -3,6 +3,8 @@ module github.com/yukithm/json2csv
 go 1.15
 
 require (
+	github.com/json-iterator/go v1.1.12
 	github.com/mitchellh/gox v1.0.1
 	github.com/urfave/cli v1.20.0
+	golang.org/x/text v0.3.7
 )
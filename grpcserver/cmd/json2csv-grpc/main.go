@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli"
+	"google.golang.org/grpc"
+
+	"github.com/yukithm/json2csv/grpcserver"
+	"github.com/yukithm/json2csv/grpcserver/json2csvpb"
+)
+
+const (
+	// ApplicationName is the name of this application.
+	ApplicationName = "json2csv-grpc"
+)
+
+// injected by build process
+var version = "unknown"
+
+func main() {
+	// Hide timestamp because this is CLI application, so just print message for users.
+	log.SetFlags(0)
+
+	app := cli.NewApp()
+	app.Name = ApplicationName
+	app.Version = version
+	app.Usage = "serve JSON to CSV conversion over gRPC"
+	app.HideHelp = true
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "listen",
+			Value: "localhost:50051",
+			Usage: "listen `ADDRESS` (host:port or unix:/path/to/socket)",
+		},
+		cli.HelpFlag,
+	}
+
+	app.Action = func(c *cli.Context) {
+		if c.Bool("help") {
+			cli.ShowAppHelp(c)
+			return
+		}
+		if err := serve(c.String("listen")); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	app.RunAndExitOnError()
+}
+
+func serve(address string) error {
+	network := "tcp"
+	if strings.HasPrefix(address, "unix:") {
+		network = "unix"
+		address = strings.TrimPrefix(address, "unix:")
+		if err := os.Remove(address); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	lis, err := net.Listen(network, address)
+	if err != nil {
+		return fmt.Errorf("Failed to listen %s: %s", address, err)
+	}
+
+	s := grpc.NewServer()
+	json2csvpb.RegisterConverterServer(s, grpcserver.NewServer())
+	return s.Serve(lis)
+}
@@ -0,0 +1,308 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: json2csvpb/json2csv.proto
+
+package json2csvpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type HeaderStyle int32
+
+const (
+	// "/foo/bar/0/baz"
+	HeaderStyle_HEADER_STYLE_JSON_POINTER HeaderStyle = 0
+	// "foo/bar/0/baz"
+	HeaderStyle_HEADER_STYLE_SLASH HeaderStyle = 1
+	// "foo.bar.0.baz"
+	HeaderStyle_HEADER_STYLE_DOT HeaderStyle = 2
+	// "foo.bar[0].baz"
+	HeaderStyle_HEADER_STYLE_DOT_BRACKET HeaderStyle = 3
+)
+
+// Enum value maps for HeaderStyle.
+var (
+	HeaderStyle_name = map[int32]string{
+		0: "HEADER_STYLE_JSON_POINTER",
+		1: "HEADER_STYLE_SLASH",
+		2: "HEADER_STYLE_DOT",
+		3: "HEADER_STYLE_DOT_BRACKET",
+	}
+	HeaderStyle_value = map[string]int32{
+		"HEADER_STYLE_JSON_POINTER": 0,
+		"HEADER_STYLE_SLASH":        1,
+		"HEADER_STYLE_DOT":          2,
+		"HEADER_STYLE_DOT_BRACKET":  3,
+	}
+)
+
+func (x HeaderStyle) Enum() *HeaderStyle {
+	p := new(HeaderStyle)
+	*p = x
+	return p
+}
+
+func (x HeaderStyle) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (HeaderStyle) Descriptor() protoreflect.EnumDescriptor {
+	return file_json2csvpb_json2csv_proto_enumTypes[0].Descriptor()
+}
+
+func (HeaderStyle) Type() protoreflect.EnumType {
+	return &file_json2csvpb_json2csv_proto_enumTypes[0]
+}
+
+func (x HeaderStyle) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use HeaderStyle.Descriptor instead.
+func (HeaderStyle) EnumDescriptor() ([]byte, []int) {
+	return file_json2csvpb_json2csv_proto_rawDescGZIP(), []int{0}
+}
+
+type Options struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	HeaderStyle HeaderStyle            `protobuf:"varint,1,opt,name=header_style,json=headerStyle,proto3,enum=json2csv.v1.HeaderStyle" json:"header_style,omitempty"`
+	// Keys (JSON Pointers) of the header.
+	Columns       []string `protobuf:"bytes,2,rep,name=columns,proto3" json:"columns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Options) Reset() {
+	*x = Options{}
+	mi := &file_json2csvpb_json2csv_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Options) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Options) ProtoMessage() {}
+
+func (x *Options) ProtoReflect() protoreflect.Message {
+	mi := &file_json2csvpb_json2csv_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Options.ProtoReflect.Descriptor instead.
+func (*Options) Descriptor() ([]byte, []int) {
+	return file_json2csvpb_json2csv_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Options) GetHeaderStyle() HeaderStyle {
+	if x != nil {
+		return x.HeaderStyle
+	}
+	return HeaderStyle_HEADER_STYLE_JSON_POINTER
+}
+
+func (x *Options) GetColumns() []string {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+type ConvertRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Options of the conversion. Only the first request's options are used.
+	Options *Options `protobuf:"bytes,1,opt,name=options,proto3" json:"options,omitempty"`
+	// JSON text of the record(s).
+	Json          []byte `protobuf:"bytes,2,opt,name=json,proto3" json:"json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertRequest) Reset() {
+	*x = ConvertRequest{}
+	mi := &file_json2csvpb_json2csv_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertRequest) ProtoMessage() {}
+
+func (x *ConvertRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_json2csvpb_json2csv_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertRequest.ProtoReflect.Descriptor instead.
+func (*ConvertRequest) Descriptor() ([]byte, []int) {
+	return file_json2csvpb_json2csv_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ConvertRequest) GetOptions() *Options {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+func (x *ConvertRequest) GetJson() []byte {
+	if x != nil {
+		return x.Json
+	}
+	return nil
+}
+
+type ConvertResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// CSV rows (including the header in the first response).
+	Csv           []byte `protobuf:"bytes,1,opt,name=csv,proto3" json:"csv,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertResponse) Reset() {
+	*x = ConvertResponse{}
+	mi := &file_json2csvpb_json2csv_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertResponse) ProtoMessage() {}
+
+func (x *ConvertResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_json2csvpb_json2csv_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertResponse.ProtoReflect.Descriptor instead.
+func (*ConvertResponse) Descriptor() ([]byte, []int) {
+	return file_json2csvpb_json2csv_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ConvertResponse) GetCsv() []byte {
+	if x != nil {
+		return x.Csv
+	}
+	return nil
+}
+
+var File_json2csvpb_json2csv_proto protoreflect.FileDescriptor
+
+const file_json2csvpb_json2csv_proto_rawDesc = "" +
+	"\n" +
+	"\x19json2csvpb/json2csv.proto\x12\vjson2csv.v1\"`\n" +
+	"\aOptions\x12;\n" +
+	"\fheader_style\x18\x01 \x01(\x0e2\x18.json2csv.v1.HeaderStyleR\vheaderStyle\x12\x18\n" +
+	"\acolumns\x18\x02 \x03(\tR\acolumns\"T\n" +
+	"\x0eConvertRequest\x12.\n" +
+	"\aoptions\x18\x01 \x01(\v2\x14.json2csv.v1.OptionsR\aoptions\x12\x12\n" +
+	"\x04json\x18\x02 \x01(\fR\x04json\"#\n" +
+	"\x0fConvertResponse\x12\x10\n" +
+	"\x03csv\x18\x01 \x01(\fR\x03csv*x\n" +
+	"\vHeaderStyle\x12\x1d\n" +
+	"\x19HEADER_STYLE_JSON_POINTER\x10\x00\x12\x16\n" +
+	"\x12HEADER_STYLE_SLASH\x10\x01\x12\x14\n" +
+	"\x10HEADER_STYLE_DOT\x10\x02\x12\x1c\n" +
+	"\x18HEADER_STYLE_DOT_BRACKET\x10\x032U\n" +
+	"\tConverter\x12H\n" +
+	"\aConvert\x12\x1b.json2csv.v1.ConvertRequest\x1a\x1c.json2csv.v1.ConvertResponse(\x010\x01B3Z1github.com/yukithm/json2csv/grpcserver/json2csvpbb\x06proto3"
+
+var (
+	file_json2csvpb_json2csv_proto_rawDescOnce sync.Once
+	file_json2csvpb_json2csv_proto_rawDescData []byte
+)
+
+func file_json2csvpb_json2csv_proto_rawDescGZIP() []byte {
+	file_json2csvpb_json2csv_proto_rawDescOnce.Do(func() {
+		file_json2csvpb_json2csv_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_json2csvpb_json2csv_proto_rawDesc), len(file_json2csvpb_json2csv_proto_rawDesc)))
+	})
+	return file_json2csvpb_json2csv_proto_rawDescData
+}
+
+var file_json2csvpb_json2csv_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_json2csvpb_json2csv_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_json2csvpb_json2csv_proto_goTypes = []any{
+	(HeaderStyle)(0),        // 0: json2csv.v1.HeaderStyle
+	(*Options)(nil),         // 1: json2csv.v1.Options
+	(*ConvertRequest)(nil),  // 2: json2csv.v1.ConvertRequest
+	(*ConvertResponse)(nil), // 3: json2csv.v1.ConvertResponse
+}
+var file_json2csvpb_json2csv_proto_depIdxs = []int32{
+	0, // 0: json2csv.v1.Options.header_style:type_name -> json2csv.v1.HeaderStyle
+	1, // 1: json2csv.v1.ConvertRequest.options:type_name -> json2csv.v1.Options
+	2, // 2: json2csv.v1.Converter.Convert:input_type -> json2csv.v1.ConvertRequest
+	3, // 3: json2csv.v1.Converter.Convert:output_type -> json2csv.v1.ConvertResponse
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_json2csvpb_json2csv_proto_init() }
+func file_json2csvpb_json2csv_proto_init() {
+	if File_json2csvpb_json2csv_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_json2csvpb_json2csv_proto_rawDesc), len(file_json2csvpb_json2csv_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_json2csvpb_json2csv_proto_goTypes,
+		DependencyIndexes: file_json2csvpb_json2csv_proto_depIdxs,
+		EnumInfos:         file_json2csvpb_json2csv_proto_enumTypes,
+		MessageInfos:      file_json2csvpb_json2csv_proto_msgTypes,
+	}.Build()
+	File_json2csvpb_json2csv_proto = out.File
+	file_json2csvpb_json2csv_proto_goTypes = nil
+	file_json2csvpb_json2csv_proto_depIdxs = nil
+}
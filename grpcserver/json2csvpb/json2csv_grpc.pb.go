@@ -0,0 +1,151 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: json2csvpb/json2csv.proto
+
+package json2csvpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Converter_Convert_FullMethodName = "/json2csv.v1.Converter/Convert"
+)
+
+// ConverterClient is the client API for Converter service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ConverterClient interface {
+	// Convert converts a stream of JSON records into a stream of CSV rows.
+	//
+	// Each request carries a JSON text (an object or an array of objects).
+	// The header is fixed by the options of the first request, or by the keys
+	// of the first record. Keys which are not in the header are ignored.
+	Convert(ctx context.Context, opts ...grpc.CallOption) (Converter_ConvertClient, error)
+}
+
+type converterClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConverterClient(cc grpc.ClientConnInterface) ConverterClient {
+	return &converterClient{cc}
+}
+
+func (c *converterClient) Convert(ctx context.Context, opts ...grpc.CallOption) (Converter_ConvertClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Converter_ServiceDesc.Streams[0], Converter_Convert_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &converterConvertClient{stream}
+	return x, nil
+}
+
+type Converter_ConvertClient interface {
+	Send(*ConvertRequest) error
+	Recv() (*ConvertResponse, error)
+	grpc.ClientStream
+}
+
+type converterConvertClient struct {
+	grpc.ClientStream
+}
+
+func (x *converterConvertClient) Send(m *ConvertRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *converterConvertClient) Recv() (*ConvertResponse, error) {
+	m := new(ConvertResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ConverterServer is the server API for Converter service.
+// All implementations must embed UnimplementedConverterServer
+// for forward compatibility
+type ConverterServer interface {
+	// Convert converts a stream of JSON records into a stream of CSV rows.
+	//
+	// Each request carries a JSON text (an object or an array of objects).
+	// The header is fixed by the options of the first request, or by the keys
+	// of the first record. Keys which are not in the header are ignored.
+	Convert(Converter_ConvertServer) error
+	mustEmbedUnimplementedConverterServer()
+}
+
+// UnimplementedConverterServer must be embedded to have forward compatible implementations.
+type UnimplementedConverterServer struct {
+}
+
+func (UnimplementedConverterServer) Convert(Converter_ConvertServer) error {
+	return status.Errorf(codes.Unimplemented, "method Convert not implemented")
+}
+func (UnimplementedConverterServer) mustEmbedUnimplementedConverterServer() {}
+
+// UnsafeConverterServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConverterServer will
+// result in compilation errors.
+type UnsafeConverterServer interface {
+	mustEmbedUnimplementedConverterServer()
+}
+
+func RegisterConverterServer(s grpc.ServiceRegistrar, srv ConverterServer) {
+	s.RegisterService(&Converter_ServiceDesc, srv)
+}
+
+func _Converter_Convert_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ConverterServer).Convert(&converterConvertServer{stream})
+}
+
+type Converter_ConvertServer interface {
+	Send(*ConvertResponse) error
+	Recv() (*ConvertRequest, error)
+	grpc.ServerStream
+}
+
+type converterConvertServer struct {
+	grpc.ServerStream
+}
+
+func (x *converterConvertServer) Send(m *ConvertResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *converterConvertServer) Recv() (*ConvertRequest, error) {
+	m := new(ConvertRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Converter_ServiceDesc is the grpc.ServiceDesc for Converter service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Converter_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "json2csv.v1.Converter",
+	HandlerType: (*ConverterServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Convert",
+			Handler:       _Converter_Convert_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "json2csvpb/json2csv.proto",
+}
@@ -0,0 +1,100 @@
+// Package grpcserver provides a gRPC service which converts JSON to CSV.
+//
+// It is a separate module so that the json2csv package doesn't depend on gRPC.
+package grpcserver
+
+//go:generate buf generate
+
+import (
+	"bytes"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/yukithm/json2csv/grpcserver/json2csvpb"
+	"github.com/yukithm/json2csv/v2"
+)
+
+var headerStyleTable = map[json2csvpb.HeaderStyle]json2csv.KeyStyle{
+	json2csvpb.HeaderStyle_HEADER_STYLE_JSON_POINTER: json2csv.JSONPointerStyle,
+	json2csvpb.HeaderStyle_HEADER_STYLE_SLASH:        json2csv.SlashStyle,
+	json2csvpb.HeaderStyle_HEADER_STYLE_DOT:          json2csv.DotNotationStyle,
+	json2csvpb.HeaderStyle_HEADER_STYLE_DOT_BRACKET:  json2csv.DotBracketStyle,
+}
+
+// Server implements json2csvpb.ConverterServer.
+type Server struct {
+	json2csvpb.UnimplementedConverterServer
+
+	conv *json2csv.Converter
+}
+
+// NewServer returns a new Server.
+func NewServer() *Server {
+	// The zero Options are valid.
+	conv, _ := json2csv.NewConverter(json2csv.Options{})
+	return &Server{conv: conv}
+}
+
+// Convert converts a stream of JSON records into a stream of CSV rows.
+// Rows are sent as soon as each request is converted, so whole payloads are
+// never buffered.
+func (s *Server) Convert(stream json2csvpb.Converter_ConvertServer) error {
+	var b bytes.Buffer
+	var w *json2csv.StreamWriter
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if w == nil {
+			w, err = newStreamWriter(&b, req.GetOptions())
+			if err != nil {
+				return err
+			}
+		}
+		if len(req.GetJson()) == 0 {
+			continue
+		}
+
+		// Each request has one JSON value, trailing data is rejected.
+		results, err := s.conv.FlattenReader(stream.Context(), bytes.NewReader(req.GetJson()))
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		for _, result := range results {
+			if err := w.WriteRecord(result); err != nil {
+				return status.Error(codes.InvalidArgument, err.Error())
+			}
+		}
+
+		if b.Len() > 0 {
+			csv := make([]byte, b.Len())
+			copy(csv, b.Bytes())
+			b.Reset()
+			if err := stream.Send(&json2csvpb.ConvertResponse{Csv: csv}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func newStreamWriter(w io.Writer, opts *json2csvpb.Options) (*json2csv.StreamWriter, error) {
+	sw := json2csv.NewStreamWriter(w)
+	if opts == nil {
+		return sw, nil
+	}
+
+	style, ok := headerStyleTable[opts.GetHeaderStyle()]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid header style %v", opts.GetHeaderStyle())
+	}
+	sw.HeaderStyle = style
+	sw.Columns = opts.GetColumns()
+	return sw, nil
+}
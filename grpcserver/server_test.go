@@ -0,0 +1,114 @@
+package grpcserver_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/yukithm/json2csv/grpcserver"
+	"github.com/yukithm/json2csv/grpcserver/json2csvpb"
+)
+
+func newClient(t *testing.T) (json2csvpb.ConverterClient, func()) {
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	json2csvpb.RegisterConverterServer(s, grpcserver.NewServer())
+	go s.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		s.Stop()
+		t.Fatal(err)
+	}
+	return json2csvpb.NewConverterClient(conn), func() {
+		conn.Close()
+		s.Stop()
+	}
+}
+
+func TestConvert(t *testing.T) {
+	client, stop := newClient(t)
+	defer stop()
+
+	stream, err := client.Convert(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := []*json2csvpb.ConvertRequest{
+		{
+			Options: &json2csvpb.Options{HeaderStyle: json2csvpb.HeaderStyle_HEADER_STYLE_DOT},
+			Json:    []byte(`{"id": 1, "user": {"name": "foo"}}`),
+		},
+		{Json: []byte(`[{"id": 2, "user": {"name": "bar"}}, {"id": 3}]`)},
+	}
+	want := []string{
+		"id,user.name\n1,foo\n",
+		"2,bar\n3,\n",
+	}
+
+	for i, req := range requests {
+		if err := stream.Send(req); err != nil {
+			t.Fatal(err)
+		}
+		res, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(res.GetCsv()); got != want[i] {
+			t.Errorf("%d: Expected %q, but %q", i, want[i], got)
+		}
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Errorf("Expected EOF, but %v", err)
+	}
+}
+
+func TestConvertInvalidArgument(t *testing.T) {
+	client, stop := newClient(t)
+	defer stop()
+
+	testCases := [][]*json2csvpb.ConvertRequest{
+		{{Json: []byte(`{"id": 1} {"id": 2}`)}},
+		{{Json: []byte(`{"id": 1}]`)}},
+		{{Json: []byte(`{"id": 1}`)}, {Json: []byte(`{"id": 2, "name": "foo"}`)}},
+		{{Options: &json2csvpb.Options{Columns: []string{"/id"}}, Json: []byte(`{"id": 1, "name": "foo"}`)}},
+	}
+	for caseIndex, requests := range testCases {
+		stream, err := client.Convert(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, req := range requests {
+			if err := stream.Send(req); err != nil {
+				t.Fatal(err)
+			}
+		}
+		stream.CloseSend()
+		for {
+			_, err = stream.Recv()
+			if err != nil {
+				break
+			}
+		}
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%d: Expected InvalidArgument, but %v", caseIndex, err)
+		}
+	}
+}
@@ -1,64 +1,93 @@
 // Package json2csv provides JSON to CSV functions.
+//
+// The package is kept for compatibility; it is a thin wrapper over
+// github.com/yukithm/json2csv/v2, which new code should use.
 package json2csv
 
 import (
-	"errors"
-	"reflect"
+	"context"
+	"time"
+
+	"github.com/yukithm/json2csv/v2"
 )
 
-// JSON2CSV converts JSON to CSV.
-func JSON2CSV(data interface{}) ([]KeyValue, error) {
-	results := []KeyValue{}
-	v := valueOf(data)
-	switch v.Kind() {
-	case reflect.Map:
-		if v.Len() > 0 {
-			result, err := flatten(v)
-			if err != nil {
-				return nil, err
-			}
-			results = append(results, result)
-		}
-	case reflect.Slice:
-		if isObjectArray(v) {
-			for i := 0; i < v.Len(); i++ {
-				result, err := flatten(v.Index(i))
-				if err != nil {
-					return nil, err
-				}
-				results = append(results, result)
-			}
-		} else if v.Len() > 0 {
-			result, err := flatten(v)
-			if err != nil {
-				return nil, err
-			}
-			if result != nil {
-				results = append(results, result)
-			}
-		}
-	default:
-		return nil, errors.New("Unsupported JSON structure.")
-	}
+// Options represents options of the conversion.
+type Options struct {
+	// MaxMemory is the approximate maximum size in bytes of the flattened
+	// results held in memory. Zero means no limit.
+	//
+	// It is a cap on the rows checked while flattening, not a limit of the
+	// whole process: the decoded JSON given to the conversion is already in
+	// memory and is not counted. With Parallelism, the rows being flattened
+	// by each goroutine are counted as they are flattened.
+	MaxMemory int64
+
+	// Timeout aborts the conversion exceeding the duration with
+	// context.DeadlineExceeded. Zero means no limit.
+	Timeout time.Duration
 
-	return results, nil
+	// Rules are applied to each row in order after flattening.
+	Rules []*Rule
+
+	// Parallelism is the number of goroutines flattening the objects of an
+	// array in chunks. The rows keep the order of the array. Zero or one
+	// flattens sequentially. FlattenOptions.Fallback is called concurrently
+	// then.
+	Parallelism int
+
+	// FlattenOptions are the options of flattening.
+	FlattenOptions
 }
 
-func isObjectArray(obj interface{}) bool {
-	value := valueOf(obj)
-	if value.Kind() != reflect.Slice {
-		return false
+// converter returns the v2 Converter of the options. Negative limits mean
+// no limit as before, instead of being rejected by v2.
+func (opts Options) converter() (*json2csv.Converter, error) {
+	o := json2csv.Options{
+		Flatten:     opts.FlattenOptions,
+		Rules:       opts.Rules,
+		MaxMemory:   opts.MaxMemory,
+		Timeout:     opts.Timeout,
+		Parallelism: opts.Parallelism,
 	}
-
-	len := value.Len()
-	if len == 0 {
-		return false
+	if o.MaxMemory < 0 {
+		o.MaxMemory = 0
+	}
+	if o.Timeout < 0 {
+		o.Timeout = 0
+	}
+	if o.Parallelism < 0 {
+		o.Parallelism = 0
 	}
-	for i := 0; i < len; i++ {
-		if valueOf(value.Index(i)).Kind() != reflect.Map {
-			return false
-		}
+	if o.Flatten.MaxDepth < 0 {
+		o.Flatten.MaxDepth = 0
 	}
+	return json2csv.NewConverter(o)
+}
+
+// ErrUnsupportedJSON is returned when the JSON is neither an object nor an
+// array.
+var ErrUnsupportedJSON = json2csv.ErrUnsupportedJSON
+
+// MemoryLimitError is returned when the flattened results exceed
+// Options.MaxMemory.
+type MemoryLimitError = json2csv.MemoryLimitError
+
+// JSON2CSV converts JSON to CSV.
+func JSON2CSV(data interface{}) ([]KeyValue, error) {
+	return JSON2CSVWithOptions(data, Options{})
+}
 
-	return true
+// JSON2CSVWithOptions converts JSON to CSV with the options.
+func JSON2CSVWithOptions(data interface{}, opts Options) ([]KeyValue, error) {
+	return JSON2CSVContext(context.Background(), data, opts)
+}
+
+// JSON2CSVContext converts JSON to CSV with the options.
+// The conversion is aborted with ctx.Err() when ctx is done.
+func JSON2CSVContext(ctx context.Context, data interface{}, opts Options) ([]KeyValue, error) {
+	c, err := opts.converter()
+	if err != nil {
+		return nil, err
+	}
+	return c.Flatten(ctx, data)
 }
@@ -2,9 +2,12 @@ package json2csv
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 )
 
 // Decode JSON with UseNumber option.
@@ -134,3 +137,242 @@ func TestJSON2CSV(t *testing.T) {
 		}
 	}
 }
+
+func TestJSON2CSVWithMaxMemory(t *testing.T) {
+	obj, err := json2obj(`[{"id": 1, "name": "foo"}, {"id": 2, "name": "bar"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := JSON2CSVWithOptions(obj, Options{MaxMemory: 1 << 20}); err != nil {
+		t.Errorf("Expected no error, but %v", err)
+	}
+
+	_, err = JSON2CSVWithOptions(obj, Options{MaxMemory: 100})
+	e, ok := err.(*MemoryLimitError)
+	if !ok {
+		t.Fatalf("Expected *MemoryLimitError, but %#v", err)
+	}
+	if e.Rows != 1 || e.Limit != 100 || e.Used <= 100 {
+		t.Errorf("Unexpected error values %#v", e)
+	}
+}
+
+func TestJSON2CSVWithNegativeLimits(t *testing.T) {
+	obj, err := json2obj(`[{"id": 1, "user": {"name": "foo"}}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// v1 has always treated negative limits as no limit.
+	opts := Options{MaxMemory: -1, Timeout: -1, Parallelism: -1}
+	opts.MaxDepth = -1
+	actual, err := JSON2CSVWithOptions(obj, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, but %v", err)
+	}
+	expected := []KeyValue{{"/id": json.Number("1"), "/user/name": "foo"}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %#v, but %#v", expected, actual)
+	}
+}
+
+func TestJSON2CSVWithParallelism(t *testing.T) {
+	var b bytes.Buffer
+	b.WriteString("[")
+	for i := 0; i < 3000; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"id": %d, "a": {"b": [%d, "x"]}}`, i, i%5)
+	}
+	b.WriteString("]")
+	obj, err := json2obj(b.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := JSON2CSVWithOptions(obj, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := JSON2CSVWithOptions(obj, Options{Parallelism: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected the same rows as sequential, but they differ")
+	}
+
+	_, err = JSON2CSVWithOptions(obj, Options{Parallelism: 4, MaxMemory: 10000})
+	if _, ok := err.(*MemoryLimitError); !ok {
+		t.Errorf("Expected *MemoryLimitError, but %#v", err)
+	}
+}
+
+func TestJSON2CSVWithRules(t *testing.T) {
+	obj, err := json2obj(`[{"type": "sale", "amount": 10}, {"type": "refund", "amount": 5, "billing": {"country": "JP"}}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rules []*Rule
+	for _, s := range []string{
+		`if /type == refund then /amount := -/amount`,
+		`if country empty then country := billing.country`,
+	} {
+		r, err := ParseRule(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, r)
+	}
+
+	actual, err := JSON2CSVWithOptions(obj, Options{Rules: rules})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []KeyValue{
+		{"/type": "sale", "/amount": json.Number("10")},
+		{"/type": "refund", "/amount": json.Number("-5"), "/billing/country": "JP", "/country": "JP"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, but %v", expected, actual)
+	}
+}
+
+func TestJSON2CSVContext(t *testing.T) {
+	obj, err := json2obj(`[{"id": 1}, {"id": 2}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := JSON2CSVContext(ctx, obj, Options{}); err != context.Canceled {
+		t.Errorf("Expected %v, but %v", context.Canceled, err)
+	}
+
+	if _, err := JSON2CSVContext(context.Background(), obj, Options{Timeout: time.Nanosecond}); err != context.DeadlineExceeded {
+		t.Errorf("Expected %v, but %v", context.DeadlineExceeded, err)
+	}
+
+	results, err := JSON2CSVContext(context.Background(), obj, Options{Timeout: time.Minute})
+	if err != nil || len(results) != 2 {
+		t.Errorf("Expected 2 rows, but %v, %v", results, err)
+	}
+}
+
+func TestJSON2CSVUnsupported(t *testing.T) {
+	data := map[string]interface{}{
+		"id":   1,
+		"ch":   make(chan int),
+		"list": []interface{}{"a", func() {}},
+	}
+	fallback := func(pointer string, value interface{}) (interface{}, error) {
+		return "<" + pointer + ">", nil
+	}
+
+	testCases := []struct {
+		opts     FlattenOptions
+		expected KeyValue
+		err      string
+	}{
+		{
+			FlattenOptions{},
+			KeyValue{"/id": int64(1), "/list/0": "a"},
+			``,
+		},
+		{
+			FlattenOptions{Unsupported: EmptyUnsupported},
+			KeyValue{"/id": int64(1), "/ch": "", "/list/0": "a", "/list/1": ""},
+			``,
+		},
+		{
+			FlattenOptions{Unsupported: ErrorUnsupported},
+			nil,
+			`Unsupported value of type chan int at "/ch"`,
+		},
+		{
+			FlattenOptions{Unsupported: FallbackUnsupported, Fallback: fallback},
+			KeyValue{"/id": int64(1), "/ch": "</ch>", "/list/0": "a", "/list/1": "</list/1>"},
+			``,
+		},
+		{
+			FlattenOptions{Unsupported: FallbackUnsupported, Fallback: func(pointer string, value interface{}) (interface{}, error) {
+				return value, nil
+			}},
+			nil,
+			`Unsupported value of type chan int at "/ch"`,
+		},
+	}
+
+	for caseIndex, testCase := range testCases {
+		actual, err := Flatten(data, testCase.opts)
+		if err != nil {
+			if err.Error() != testCase.err {
+				t.Errorf("%d: Expected %v, but %v", caseIndex, testCase.err, err)
+			}
+		} else if testCase.err != "" {
+			t.Errorf("%d: Expected %v, but no error", caseIndex, testCase.err)
+		} else if !reflect.DeepEqual([]KeyValue{testCase.expected}, actual) {
+			t.Errorf("%d: Expected %#v, but %#v", caseIndex, testCase.expected, actual)
+		}
+	}
+}
+
+func TestJSON2CSVBinary(t *testing.T) {
+	blob := []byte("hello, world")
+	encoded := "aGVsbG8sIHdvcmxk"
+	data := map[string]interface{}{
+		"blob":    blob,
+		"encoded": encoded,
+		"digest":  "0123456789abcdef",
+		"text":    "word",
+	}
+
+	testCases := []struct {
+		opts     FlattenOptions
+		expected KeyValue
+	}{
+		{
+			FlattenOptions{Binary: Base64Binary, Base64MinLength: 16},
+			KeyValue{"/blob": encoded, "/encoded": encoded, "/digest": "0123456789abcdef", "/text": "word"},
+		},
+		{
+			FlattenOptions{Binary: HexBinary, Base64MinLength: 16},
+			KeyValue{"/blob": "68656c6c6f2c20776f726c64", "/encoded": "68656c6c6f2c20776f726c64", "/digest": "0123456789abcdef", "/text": "word"},
+		},
+		{
+			FlattenOptions{Binary: LengthBinary, Base64MinLength: 16},
+			KeyValue{"/blob": "[binary 12 bytes]", "/encoded": "[binary 12 bytes]", "/digest": "0123456789abcdef", "/text": "word"},
+		},
+		{
+			FlattenOptions{Binary: LengthBinary},
+			KeyValue{"/blob": "[binary 12 bytes]", "/encoded": encoded, "/digest": "0123456789abcdef", "/text": "word"},
+		},
+		{
+			FlattenOptions{Binary: SkipBinary, Base64MinLength: 4},
+			KeyValue{"/digest": "0123456789abcdef"},
+		},
+		{
+			FlattenOptions{Binary: SkipBinary, Base64MinLength: 4, Base64Keys: []string{"/encoded"}},
+			KeyValue{"/digest": "0123456789abcdef", "/text": "word"},
+		},
+	}
+
+	for caseIndex, testCase := range testCases {
+		actual, err := Flatten(data, testCase.opts)
+		if err != nil {
+			t.Errorf("%d: Unexpected error %v", caseIndex, err)
+		} else if !reflect.DeepEqual([]KeyValue{testCase.expected}, actual) {
+			t.Errorf("%d: Expected %#v, but %#v", caseIndex, testCase.expected, actual)
+		}
+	}
+
+	actual, err := JSON2CSV(map[string]interface{}{"blob": []byte{1, 2}})
+	expected := []KeyValue{{"/blob/0": uint64(1), "/blob/1": uint64(2)}}
+	if err != nil || !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %#v, but %#v, %v", expected, actual, err)
+	}
+}
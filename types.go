@@ -0,0 +1,384 @@
+package json2csv
+
+import (
+	"io"
+	"regexp"
+
+	"github.com/yukithm/json2csv/v2"
+)
+
+// KeyValue is a flattened row: JSON Pointers to the values.
+type KeyValue = json2csv.KeyValue
+
+// FlattenOptions are the options of flattening JSON into rows.
+type FlattenOptions = json2csv.FlattenOptions
+
+// ArrayStyle specifies how arrays are flattened.
+type ArrayStyle = json2csv.ArrayStyle
+
+// Array styles
+const (
+	IndexArrays = json2csv.IndexArrays
+	JoinArrays  = json2csv.JoinArrays
+	JSONArrays  = json2csv.JSONArrays
+)
+
+// BinaryPolicy specifies how binary values are converted.
+type BinaryPolicy = json2csv.BinaryPolicy
+
+// Binary policies
+const (
+	ArrayBinary  = json2csv.ArrayBinary
+	Base64Binary = json2csv.Base64Binary
+	HexBinary    = json2csv.HexBinary
+	LengthBinary = json2csv.LengthBinary
+	SkipBinary   = json2csv.SkipBinary
+)
+
+// UnsupportedPolicy specifies how values of unsupported types are converted.
+type UnsupportedPolicy = json2csv.UnsupportedPolicy
+
+// Unsupported policies
+const (
+	SkipUnsupported     = json2csv.SkipUnsupported
+	EmptyUnsupported    = json2csv.EmptyUnsupported
+	ErrorUnsupported    = json2csv.ErrorUnsupported
+	FallbackUnsupported = json2csv.FallbackUnsupported
+)
+
+// KeyStyle is the style of the header names.
+type KeyStyle = json2csv.KeyStyle
+
+// Header styles
+const (
+	JSONPointerStyle = json2csv.JSONPointerStyle
+	SlashStyle       = json2csv.SlashStyle
+	DotNotationStyle = json2csv.DotNotationStyle
+	DotBracketStyle  = json2csv.DotBracketStyle
+)
+
+// TruncationStyle specifies how to shorten long header names.
+type TruncationStyle = json2csv.TruncationStyle
+
+// Truncation styles
+const (
+	TruncateStyle   = json2csv.TruncateStyle
+	HashSuffixStyle = json2csv.HashSuffixStyle
+)
+
+// ProtectionStyle specifies how to protect numeric-looking strings.
+type ProtectionStyle = json2csv.ProtectionStyle
+
+// Protection styles
+const (
+	NoProtection      = json2csv.NoProtection
+	QuoteProtection   = json2csv.QuoteProtection
+	FormulaProtection = json2csv.FormulaProtection
+)
+
+// QuoteStyle specifies when fields are quoted.
+type QuoteStyle = json2csv.QuoteStyle
+
+// Quote styles
+const (
+	QuoteMinimal = json2csv.QuoteMinimal
+	QuoteAll     = json2csv.QuoteAll
+	QuoteNone    = json2csv.QuoteNone
+)
+
+// Dialect is a set of the delimiters and the quoting.
+type Dialect = json2csv.Dialect
+
+// Predefined dialects
+var (
+	CSVDialect   = json2csv.CSVDialect
+	TSVDialect   = json2csv.TSVDialect
+	ASCIIDialect = json2csv.ASCIIDialect
+)
+
+// Translator translates header names.
+type Translator = json2csv.Translator
+
+// TranslatorFunc is an adapter to use a function as a Translator.
+type TranslatorFunc = json2csv.TranslatorFunc
+
+// TranslationMap translates keys or header names.
+type TranslationMap = json2csv.TranslationMap
+
+// Translations are TranslationMaps by locale.
+type Translations = json2csv.Translations
+
+// Transform rewrites the string values of a column.
+type Transform = json2csv.Transform
+
+// Built-in transforms
+var (
+	UpperCase    = json2csv.UpperCase
+	LowerCase    = json2csv.LowerCase
+	TitleCase    = json2csv.TitleCase
+	StripAccents = json2csv.StripAccents
+)
+
+// ParseTransform parses the transform spec, e.g. "upper" or
+// "replace:/PATTERN/REPLACEMENT/".
+func ParseTransform(spec string) (Transform, error) {
+	return json2csv.ParseTransform(spec)
+}
+
+// ReplaceTransform replaces the matches of re with repl.
+func ReplaceTransform(re *regexp.Regexp, repl string) Transform {
+	return json2csv.ReplaceTransform(re, repl)
+}
+
+// ExtractTransform returns the submatch of the group of the first match of re.
+func ExtractTransform(re *regexp.Regexp, group int) Transform {
+	return json2csv.ExtractTransform(re, group)
+}
+
+// NumberFormat formats the numeric values of a column.
+type NumberFormat = json2csv.NumberFormat
+
+// ParseNumberFormat parses the format spec, e.g. "currency:$" or "bytes:MB".
+func ParseNumberFormat(spec string) (NumberFormat, error) {
+	return json2csv.ParseNumberFormat(spec)
+}
+
+// Rule is a conditional assignment evaluated on each row.
+type Rule = json2csv.Rule
+
+// ParseRule parses the rule, e.g. "if /type == refund then /amount := -/amount".
+func ParseRule(s string) (*Rule, error) {
+	return json2csv.ParseRule(s)
+}
+
+// RecordWriter writes the header and the records of an output format.
+type RecordWriter = json2csv.RecordWriter
+
+// TypedRecordWriter is a RecordWriter which keeps the types of the values.
+type TypedRecordWriter = json2csv.TypedRecordWriter
+
+// DelimitedRecordWriter is a RecordWriter of delimited text, e.g. CSV or TSV.
+type DelimitedRecordWriter = json2csv.DelimitedRecordWriter
+
+// NewCSVRecordWriter returns a DelimitedRecordWriter which writes CSV in the
+// dialect.
+func NewCSVRecordWriter(w io.Writer, d Dialect) RecordWriter {
+	return json2csv.NewCSVRecordWriter(w, d)
+}
+
+// XLSXRecordWriter is a RecordWriter which writes an Excel workbook.
+type XLSXRecordWriter = json2csv.XLSXRecordWriter
+
+// NewXLSXRecordWriter returns new XLSXRecordWriter.
+func NewXLSXRecordWriter(w io.Writer) *XLSXRecordWriter {
+	return json2csv.NewXLSXRecordWriter(w)
+}
+
+// StreamWriter writes CSV record by record.
+type StreamWriter = json2csv.StreamWriter
+
+// TransposeWriter writes transposed CSV record by record.
+type TransposeWriter = json2csv.TransposeWriter
+
+// ColumnStats is the statistics of the cells of a column.
+type ColumnStats = json2csv.ColumnStats
+
+// SelfCheckError is returned by CSVWriter.WriteCSV with SelfCheck if the
+// output is not valid CSV.
+type SelfCheckError = json2csv.SelfCheckError
+
+// UnsupportedValueError is returned when a value of an unsupported type is
+// found with ErrorUnsupported.
+type UnsupportedValueError = json2csv.UnsupportedValueError
+
+// DroppedKeysError is returned by StreamWriter when a record has keys which
+// are not in the header.
+type DroppedKeysError = json2csv.DroppedKeysError
+
+// XLSXLimitError is returned by XLSXRecordWriter when the rows or the
+// columns exceed the limits of an Excel worksheet.
+type XLSXLimitError = json2csv.XLSXLimitError
+
+// Flatten flattens the object into a row, or the array of objects into the
+// rows.
+func Flatten(v interface{}, opts FlattenOptions) ([]KeyValue, error) {
+	return json2csv.Flatten(v, opts)
+}
+
+// GenerateJSON returns n synthetic objects which have values at all the keys
+// of the schema.
+func GenerateJSON(schema []string, n int) ([]interface{}, error) {
+	return json2csv.GenerateJSON(schema, n)
+}
+
+// CSVHeader is the columns of CSV as keys (JSON Pointers).
+type CSVHeader = json2csv.CSVHeader
+
+// Collision is a header name shared by several keys in a header style.
+type Collision = json2csv.Collision
+
+// HeaderDiff is the differences between two headers.
+type HeaderDiff = json2csv.HeaderDiff
+
+// NewCSVHeader returns the sorted keys of the results.
+func NewCSVHeader(results []KeyValue) (CSVHeader, error) {
+	return json2csv.NewCSVHeader(results)
+}
+
+// NewCSVHeaderFromStrings returns the header of the keys (JSON Pointers).
+func NewCSVHeaderFromStrings(keys []string) (CSVHeader, error) {
+	return json2csv.NewCSVHeaderFromStrings(keys)
+}
+
+// CompareHeaders compares the old header a and the new header b.
+func CompareHeaders(a, b CSVHeader) HeaderDiff {
+	return json2csv.CompareHeaders(a, b)
+}
+
+// Writer writes CSV records.
+type Writer = json2csv.Writer
+
+// NewWriter returns a new Writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return json2csv.NewWriter(w)
+}
+
+// CSVWriter writes CSV data.
+type CSVWriter = json2csv.CSVWriter
+
+// NewCSVWriter returns new CSVWriter with JSONPointerStyle.
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return json2csv.NewCSVWriter(w)
+}
+
+// NewStreamWriter returns new StreamWriter with JSONPointerStyle.
+func NewStreamWriter(w io.Writer) *StreamWriter {
+	return json2csv.NewStreamWriter(w)
+}
+
+// NewTransposeWriter returns new TransposeWriter with JSONPointerStyle.
+func NewTransposeWriter(w io.Writer) *TransposeWriter {
+	return json2csv.NewTransposeWriter(w)
+}
+
+// RecordSpool holds records in a temporary file.
+type RecordSpool = json2csv.RecordSpool
+
+// FormatFunc returns a RecordWriter which writes the format to w.
+type FormatFunc = json2csv.FormatFunc
+
+// RegisterFormat makes an output format available by the name.
+func RegisterFormat(name string, f FormatFunc) {
+	json2csv.RegisterFormat(name, f)
+}
+
+// LookupFormat returns the output format registered by the name.
+func LookupFormat(name string) (FormatFunc, bool) {
+	return json2csv.LookupFormat(name)
+}
+
+// FormatNames returns the sorted names of the registered output formats.
+func FormatNames() []string {
+	return json2csv.FormatNames()
+}
+
+// ColumnType represents the cell type of a column in XLSX.
+type ColumnType = json2csv.ColumnType
+
+// Column types
+const (
+	GeneralType = json2csv.GeneralType
+	TextType    = json2csv.TextType
+	NumberType  = json2csv.NumberType
+	DateType    = json2csv.DateType
+)
+
+// Decoder reads JSON values from an input stream.
+type Decoder = json2csv.Decoder
+
+// DecoderFactory creates a Decoder which reads from r.
+type DecoderFactory = json2csv.DecoderFactory
+
+// RegisterDecoder makes a decoder backend available by the name.
+func RegisterDecoder(name string, factory DecoderFactory) {
+	json2csv.RegisterDecoder(name, factory)
+}
+
+// LookupDecoder returns the decoder backend registered by the name.
+func LookupDecoder(name string) (DecoderFactory, bool) {
+	return json2csv.LookupDecoder(name)
+}
+
+// DecoderNames returns the sorted names of the registered decoder backends.
+func DecoderNames() []string {
+	return json2csv.DecoderNames()
+}
+
+// NewStdDecoder returns a Decoder using encoding/json.
+func NewStdDecoder(r io.Reader) Decoder {
+	return json2csv.NewStdDecoder(r)
+}
+
+// Source opens inputs by the location.
+type Source = json2csv.Source
+
+// Sink creates outputs by the location.
+type Sink = json2csv.Sink
+
+// SourceFunc is an adapter to use a function as a Source.
+type SourceFunc = json2csv.SourceFunc
+
+// SinkFunc is an adapter to use a function as a Sink.
+type SinkFunc = json2csv.SinkFunc
+
+// RegisterSource makes a source available for the URL scheme.
+func RegisterSource(scheme string, source Source) {
+	json2csv.RegisterSource(scheme, source)
+}
+
+// RegisterSink makes a sink available for the URL scheme.
+func RegisterSink(scheme string, sink Sink) {
+	json2csv.RegisterSink(scheme, sink)
+}
+
+// LookupSource returns the source registered for the URL scheme.
+func LookupSource(scheme string) (Source, bool) {
+	return json2csv.LookupSource(scheme)
+}
+
+// LookupSink returns the sink registered for the URL scheme.
+func LookupSink(scheme string) (Sink, bool) {
+	return json2csv.LookupSink(scheme)
+}
+
+// SourceSchemes returns the sorted URL schemes of the registered sources.
+func SourceSchemes() []string {
+	return json2csv.SourceSchemes()
+}
+
+// SinkSchemes returns the sorted URL schemes of the registered sinks.
+func SinkSchemes() []string {
+	return json2csv.SinkSchemes()
+}
+
+// OpenSource opens the input by the source registered for the URL scheme of
+// the location. Locations without a scheme are local files.
+func OpenSource(location string) (io.ReadCloser, error) {
+	return json2csv.OpenSource(location)
+}
+
+// CreateSink creates the output by the sink registered for the URL scheme of
+// the location. Locations without a scheme are local files.
+func CreateSink(location string) (io.WriteCloser, error) {
+	return json2csv.CreateSink(location)
+}
+
+// IsLocalLocation reports whether the location is a local file.
+func IsLocalLocation(location string) bool {
+	return json2csv.IsLocalLocation(location)
+}
+
+// LocalPath returns the file path of the local location.
+func LocalPath(location string) string {
+	return json2csv.LocalPath(location)
+}
@@ -0,0 +1,217 @@
+package json2csv
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SelfCheckError is returned by CSVWriter.WriteCSV with SelfCheck if the
+// output is not valid by RFC 4180, or doesn't have the records and fields
+// which were written.
+type SelfCheckError struct {
+	Record int // record number starting from 1, or 0 for the counts
+	Reason string
+}
+
+func (e *SelfCheckError) Error() string {
+	if e.Record == 0 {
+		return "Self-check failed: " + e.Reason
+	}
+	return fmt.Sprintf("Self-check failed at record %d: %s", e.Record, e.Reason)
+}
+
+// writeChecked writes CSV data into a buffer, parses it strictly and verifies
+// the number of records and fields, and then copies it to the output, so
+// that broken output is never written.
+func (w *CSVWriter) writeChecked(results []KeyValue) error {
+	keys, header, err := w.columns(results)
+	if err != nil {
+		return err
+	}
+	headerRows, err := w.headerRows(keys, header)
+	if err != nil {
+		return err
+	}
+	records, fields := len(headerRows)+len(results), len(keys)
+	if w.Transpose {
+		records, fields = fields, records
+	}
+
+	var buf bytes.Buffer
+	w.Flush()
+	csvWriter, out := w.Writer, w.out
+	w.Reset(&buf)
+	if w.Transpose {
+		err = w.writeTransposedCSV(results)
+	} else {
+		err = w.writeCSV(results)
+	}
+	w.Flush()
+	w.Writer, w.out = csvWriter, out
+	if err != nil {
+		return err
+	}
+
+	parsedRecords, parsedFields, err := w.newChecker(bytes.NewReader(buf.Bytes())).check()
+	if err != nil {
+		return err
+	}
+	// A record without fields can't be distinguished from an empty field.
+	if fields > 0 && (parsedRecords != records || parsedFields != fields) {
+		return &SelfCheckError{Reason: fmt.Sprintf("wrote %d records of %d fields, but parsed %d records of %d fields", records, fields, parsedRecords, parsedFields)}
+	}
+
+	if _, err := out.w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// checker parses CSV strictly by RFC 4180 with the delimiter and the
+// terminator of the writer:
+//   - quoted fields have no characters outside the quotes
+//   - quotes in quoted fields are doubled
+//   - unquoted fields have no quotes (nor line breaks) if the terminator is
+//     a line break; with another terminator (e.g. "\x1e" of ASCIIDialect),
+//     they may contain quotes after the first character, which Writer leaves
+//     unquoted with QuoteNone
+//   - every record ends with the terminator
+//   - all records have the same number of fields
+type checker struct {
+	r            *bufio.Reader
+	comma        string
+	terminator   string
+	commentLines int
+}
+
+func (w *CSVWriter) newChecker(r io.Reader) *checker {
+	terminator := w.Terminator
+	if terminator == "" {
+		terminator = "\n"
+		if w.UseCRLF {
+			terminator = "\r\n"
+		}
+	}
+
+	comments := 0
+	for _, comment := range w.Comments {
+		comments += strings.Count(strings.Replace(comment, "\r\n", "\n", -1), "\n") + 1
+	}
+	return &checker{
+		r:            bufio.NewReader(r),
+		comma:        string(w.Comma),
+		terminator:   terminator,
+		commentLines: comments,
+	}
+}
+
+// check returns the number of records and the number of fields of each.
+func (c *checker) check() (records int, fields int, err error) {
+	for i := 0; i < c.commentLines; i++ {
+		if err := c.skipLine(); err != nil {
+			return 0, 0, &SelfCheckError{Reason: "comment line without terminator"}
+		}
+	}
+
+	for {
+		if _, err := c.r.Peek(1); err == io.EOF {
+			return records, fields, nil
+		} else if err != nil {
+			return 0, 0, err
+		}
+
+		records++
+		n, err := c.readRecord(records)
+		if err != nil {
+			return 0, 0, err
+		}
+		if records == 1 {
+			fields = n
+		} else if n != fields {
+			return 0, 0, &SelfCheckError{records, fmt.Sprintf("%d fields, but the first record has %d", n, fields)}
+		}
+	}
+}
+
+func (c *checker) skipLine() error {
+	for !c.consume(c.terminator) {
+		if _, err := c.r.ReadByte(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readRecord returns the number of fields of the record.
+func (c *checker) readRecord(record int) (int, error) {
+	for n := 1; ; n++ {
+		end, err := c.readField(record)
+		if err != nil {
+			return 0, err
+		}
+		if end {
+			return n, nil
+		}
+	}
+}
+
+// readField reads a field and the following delimiter. It reports whether
+// the record ends.
+func (c *checker) readField(record int) (bool, error) {
+	if c.consume(`"`) {
+		for {
+			b, err := c.r.ReadByte()
+			if err == io.EOF {
+				return false, &SelfCheckError{record, "unterminated quoted field"}
+			} else if err != nil {
+				return false, err
+			}
+			if b == '"' && !c.consume(`"`) {
+				break
+			}
+		}
+		if c.consume(c.comma) {
+			return false, nil
+		}
+		if c.consume(c.terminator) {
+			return true, nil
+		}
+		return false, &SelfCheckError{record, "extraneous characters after quoted field"}
+	}
+
+	lineBreak := c.terminator == "\n" || c.terminator == "\r\n"
+	for {
+		if c.consume(c.comma) {
+			return false, nil
+		}
+		if c.consume(c.terminator) {
+			return true, nil
+		}
+		b, err := c.r.ReadByte()
+		if err == io.EOF {
+			return false, &SelfCheckError{record, "missing record terminator"}
+		} else if err != nil {
+			return false, err
+		}
+		if b == '"' && lineBreak {
+			return false, &SelfCheckError{record, "bare quote in unquoted field"}
+		}
+		if lineBreak && (b == '\r' || b == '\n') {
+			return false, &SelfCheckError{record, "line break in unquoted field"}
+		}
+	}
+}
+
+// consume reads s if it comes next.
+func (c *checker) consume(s string) bool {
+	p, err := c.r.Peek(len(s))
+	if err != nil || string(p) != s {
+		return false
+	}
+	c.r.Discard(len(s))
+	return true
+}
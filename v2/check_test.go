@@ -0,0 +1,100 @@
+package json2csv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestChecker(t *testing.T) {
+	testCases := []struct {
+		csv        string
+		comma      rune
+		terminator string
+		records    int
+		fields     int
+		err        string
+	}{
+		{"a,b\n1,2\n", ',', "", 2, 2, ``},
+		{"a,b\n\"x,\"\"y\"\"\nz\",2\n", ',', "", 2, 2, ``},
+		{"a,b\r\n1,2\r\n", ',', "\r\n", 2, 2, ``},
+		{"a\tb\x1e1\t2\x1e", '\t', "\x1e", 2, 2, ``},
+		{"a\x1fb\x1e1\x1fx\"y\x1e", '\x1f', "\x1e", 2, 2, ``},
+		{"", ',', "", 0, 0, ``},
+		{"a,b\n1\n", ',', "", 0, 0, `Self-check failed at record 2: 1 fields, but the first record has 2`},
+		{"a,b\n1,x\"y\n", ',', "", 0, 0, `Self-check failed at record 2: bare quote in unquoted field`},
+		{"a,b\n\"1\"x,2\n", ',', "", 0, 0, `Self-check failed at record 2: extraneous characters after quoted field`},
+		{"a,b\n\"1,2\n", ',', "", 0, 0, `Self-check failed at record 2: unterminated quoted field`},
+		{"a,b\n1,2", ',', "", 0, 0, `Self-check failed at record 2: missing record terminator`},
+		{"a,b\r\n1\r,2\r\n", ',', "\r\n", 0, 0, `Self-check failed at record 2: line break in unquoted field`},
+	}
+
+	for caseIndex, testCase := range testCases {
+		w := NewCSVWriter(&bytes.Buffer{})
+		w.Comma = testCase.comma
+		w.Terminator = testCase.terminator
+		records, fields, err := w.newChecker(strings.NewReader(testCase.csv)).check()
+		if err != nil {
+			if err.Error() != testCase.err {
+				t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.err, err)
+			}
+		} else if testCase.err != "" {
+			t.Errorf("%d: Expected %q, but no error", caseIndex, testCase.err)
+		} else if records != testCase.records || fields != testCase.fields {
+			t.Errorf("%d: Expected %d records of %d fields, but %d of %d", caseIndex, testCase.records, testCase.fields, records, fields)
+		}
+	}
+}
+
+func TestSelfCheck(t *testing.T) {
+	results := []KeyValue{
+		{"/id": 1, "/name": "foo, \"bar\"\nbaz"},
+		{"/id": 2},
+	}
+
+	for _, transpose := range []bool{false, true} {
+		b := &bytes.Buffer{}
+		w := NewCSVWriter(b)
+		w.SelfCheck = true
+		w.Transpose = transpose
+		w.GroupHeader = true
+		w.Comments = []string{"generated\nby test"}
+		if err := w.WriteCSV(results); err != nil {
+			t.Errorf("transpose=%v: Unexpected error %v", transpose, err)
+		}
+
+		expected := &bytes.Buffer{}
+		w = NewCSVWriter(expected)
+		w.Transpose = transpose
+		w.GroupHeader = true
+		w.Comments = []string{"generated\nby test"}
+		w.WriteCSV(results)
+		if b.String() != expected.String() {
+			t.Errorf("transpose=%v: Expected %q, but %q", transpose, expected.String(), b.String())
+		}
+	}
+
+	b := &bytes.Buffer{}
+	w := NewCSVWriter(b)
+	w.SelfCheck = true
+	w.SetDialect(ASCIIDialect)
+	if err := w.WriteCSV([]KeyValue{{"/a": "x\"y"}}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if expected := "/a\x1ex\"y\x1e"; b.String() != expected {
+		t.Errorf("Expected %q, but %q", expected, b.String())
+	}
+
+	b = &bytes.Buffer{}
+	w = NewCSVWriter(b)
+	w.SelfCheck = true
+	w.SetDialect(ASCIIDialect)
+	w.Comments = []string{"broken\x1ecomment"}
+	err := w.WriteCSV(results)
+	if _, ok := err.(*SelfCheckError); !ok {
+		t.Errorf("Expected *SelfCheckError, but %v", err)
+	}
+	if b.Len() > 0 {
+		t.Errorf("Expected no output, but %q", b.String())
+	}
+}
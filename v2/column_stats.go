@@ -0,0 +1,73 @@
+package json2csv
+
+import "hash/fnv"
+
+// ColumnStats is the statistics of the cells of a column, e.g. to decide
+// which columns to drop or hash before producing a large output.
+type ColumnStats struct {
+	Key  string `json:"key"`  // JSON Pointer
+	Name string `json:"name"` // header name
+
+	// Values is the number of non-empty cells.
+	Values int `json:"values"`
+
+	// Cardinality is the number of distinct non-empty values. Values are
+	// compared by 64-bit hashes, so it may be slightly underestimated.
+	Cardinality int `json:"cardinality"`
+
+	// AvgWidth is the average size of the cells in bytes, including empty
+	// ones. Quotes and delimiters are not included.
+	AvgWidth float64 `json:"avg_width"`
+
+	// MaxWidth is the size of the largest cell in bytes.
+	MaxWidth int `json:"max_width"`
+}
+
+// ColumnStats returns the statistics of the columns which WriteCSV would
+// write, with the values formatted by the settings of the writer.
+func (w *CSVWriter) ColumnStats(results []KeyValue) ([]ColumnStats, error) {
+	keys, header, err := w.columns(results)
+	if err != nil {
+		return nil, err
+	}
+
+	index := newColumnIndex(keys)
+	format := w.formatter(keys, header)
+	stats := make([]ColumnStats, len(keys))
+	seen := make([]map[uint64]struct{}, len(keys))
+	widths := make([]int64, len(keys))
+	for i := range keys {
+		stats[i] = ColumnStats{Key: keys[i], Name: header[i]}
+		seen[i] = make(map[uint64]struct{})
+	}
+
+	h := fnv.New64a()
+	for _, kv := range results {
+		for key, value := range kv {
+			i, ok := index.position[key]
+			if !ok {
+				continue
+			}
+			s := format(i, value).value
+			if s == "" {
+				continue
+			}
+			stats[i].Values++
+			widths[i] += int64(len(s))
+			if len(s) > stats[i].MaxWidth {
+				stats[i].MaxWidth = len(s)
+			}
+			h.Reset()
+			h.Write([]byte(s))
+			seen[i][h.Sum64()] = struct{}{}
+		}
+	}
+
+	for i := range stats {
+		stats[i].Cardinality = len(seen[i])
+		if len(results) > 0 {
+			stats[i].AvgWidth = float64(widths[i]) / float64(len(results))
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,172 @@
+// Package json2csv provides JSON to CSV conversion.
+//
+// A Converter is created from Options once, and converts JSON values or
+// streams with the options:
+//
+//	c, err := json2csv.NewConverter(json2csv.Options{
+//		Writer: json2csv.WriterOptions{HeaderStyle: json2csv.DotNotationStyle},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	err = c.ConvertReader(ctx, os.Stdout, os.Stdin)
+//
+// The package holds the implementation; the functions and types of
+// github.com/yukithm/json2csv are thin wrappers over it.
+package json2csv
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/yukithm/json2csv/v2/jsonpointer"
+)
+
+// errTrailingData is the error of DecodeError when the input has data after
+// the JSON value.
+var errTrailingData = errors.New("data after the JSON value")
+
+// Converter converts JSON to CSV with the options.
+// The methods don't modify the Converter, so goroutines can share it as long
+// as the io.Writers and the functions of the options (e.g.
+// WriterOptions.Overflow and FlattenOptions.Fallback) are safe for
+// concurrent use.
+type Converter struct {
+	opts Options
+}
+
+// NewConverter returns new Converter, or *OptionError if an option is
+// invalid. The options must not be modified after the call.
+func NewConverter(opts Options) (*Converter, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	return &Converter{opts: opts}, nil
+}
+
+// Options returns the options of the converter.
+func (c *Converter) Options() Options {
+	return c.opts
+}
+
+// Flatten converts JSON (an object or an array of objects) to the rows.
+// It returns ErrUnsupportedJSON for other values.
+func (c *Converter) Flatten(ctx context.Context, data interface{}) ([]KeyValue, error) {
+	return appendRows(ctx, []KeyValue{}, data, &c.opts)
+}
+
+// Convert converts JSON and writes CSV to w.
+// Nothing is written if there are no rows.
+func (c *Converter) Convert(ctx context.Context, w io.Writer, data interface{}) error {
+	rows, err := c.Flatten(ctx, data)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return c.WriteCSV(ctx, w, rows)
+}
+
+// ConvertReader reads a JSON value from r by the decoder backend, and
+// converts it (or the value at Options.Path) to CSV. It returns *DecodeError
+// if the input is not valid JSON or has data after the value.
+// Nothing is written if there are no rows.
+func (c *Converter) ConvertReader(ctx context.Context, w io.Writer, r io.Reader) error {
+	rows, err := c.FlattenReader(ctx, r)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return c.WriteCSV(ctx, w, rows)
+}
+
+// FlattenReader reads a JSON value from r by the decoder backend, and
+// converts it (or the value at Options.Path) to the rows like ConvertReader.
+func (c *Converter) FlattenReader(ctx context.Context, r io.Reader) ([]KeyValue, error) {
+	name := c.opts.Decoder
+	if name == "" {
+		name = "std"
+	}
+	// already validated
+	factory, _ := LookupDecoder(name)
+	decoder := factory(r)
+	data, err := decoder.Decode()
+	if err != nil {
+		return nil, &DecodeError{Err: err}
+	}
+	if _, err := decoder.Decode(); err != io.EOF {
+		return nil, &DecodeError{Err: errTrailingData}
+	}
+
+	if c.opts.Path != "" {
+		data, err = jsonpointer.Get(data, c.opts.Path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.Flatten(ctx, data)
+}
+
+// WriteCSV writes the rows as CSV to w. The writing is aborted with
+// ctx.Err() when ctx is done.
+func (c *Converter) WriteCSV(ctx context.Context, w io.Writer, rows []KeyValue) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	csv := c.newCSVWriter(w)
+	csv.ctx = ctx
+	if err := csv.WriteCSV(rows); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// WriteRecords writes the rows to the RecordWriter, e.g. of another output
+// format, with the header names and the values of the options. It doesn't
+// close rw.
+func (c *Converter) WriteRecords(ctx context.Context, rw RecordWriter, rows []KeyValue) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	csv := c.newCSVWriter(ioutil.Discard)
+	csv.ctx = ctx
+	return csv.WriteRecords(rw, rows)
+}
+
+// ColumnStats returns the statistics of the columns which WriteCSV would
+// write.
+func (c *Converter) ColumnStats(rows []KeyValue) ([]ColumnStats, error) {
+	return c.newCSVWriter(ioutil.Discard).ColumnStats(rows)
+}
+
+// NewStreamWriter returns a StreamWriter with the writer options, which
+// writes each record as soon as it is written. The header is fixed by
+// WriterOptions.Columns, or by the keys of the first record.
+func (c *Converter) NewStreamWriter(w io.Writer) *StreamWriter {
+	sw := NewStreamWriter(w)
+	c.opts.configure(sw.CSVWriter)
+	return sw
+}
+
+// NewTransposeWriter returns a TransposeWriter with the writer options,
+// which spools the records to a temporary file and writes the transposed
+// CSV on Close.
+func (c *Converter) NewTransposeWriter(w io.Writer) *TransposeWriter {
+	tw := NewTransposeWriter(w)
+	c.opts.configure(tw.CSVWriter)
+	return tw
+}
+
+func (c *Converter) newCSVWriter(w io.Writer) *CSVWriter {
+	csv := NewCSVWriter(w)
+	c.opts.configure(csv)
+	return csv
+}
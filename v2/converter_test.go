@@ -0,0 +1,184 @@
+package json2csv_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yukithm/json2csv/v2"
+)
+
+func TestConverter(t *testing.T) {
+	testCases := []struct {
+		opts json2csv.Options
+		in   string
+		want string
+	}{
+		{
+			json2csv.Options{},
+			`[{"id": 1, "a": {"b": "x"}}, {"id": 2}]`,
+			"/id,/a/b\n1,x\n2,\n",
+		},
+		{
+			json2csv.Options{
+				Writer: json2csv.WriterOptions{
+					HeaderStyle:  json2csv.DotNotationStyle,
+					Dialect:      json2csv.TSVDialect,
+					ColumnsFirst: []string{"a.b"},
+				},
+			},
+			`[{"id": 1, "a": {"b": "x"}}, {"id": 2}]`,
+			"a.b\tid\nx\t1\n\t2\n",
+		},
+		{
+			json2csv.Options{
+				Flatten: json2csv.FlattenOptions{Arrays: json2csv.JoinArrays},
+				Writer:  json2csv.WriterOptions{Transpose: true},
+			},
+			`{"id": 1, "tags": ["a", "b"]}`,
+			"/id,1\n/tags,\"a,b\"\n",
+		},
+		{
+			json2csv.Options{},
+			`[]`,
+			"",
+		},
+		{
+			json2csv.Options{Path: "/items"},
+			"{\"items\": [{\"id\": 1}]}\n",
+			"/id\n1\n",
+		},
+	}
+
+	for caseIndex, testCase := range testCases {
+		c, err := json2csv.NewConverter(testCase.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := &bytes.Buffer{}
+		if err := c.ConvertReader(context.Background(), b, strings.NewReader(testCase.in)); err != nil {
+			t.Fatalf("%d: %s", caseIndex, err)
+		}
+		if b.String() != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, b.String())
+		}
+	}
+}
+
+func TestConverterErrors(t *testing.T) {
+	_, err := json2csv.NewConverter(json2csv.Options{
+		Writer: json2csv.WriterOptions{Dialect: json2csv.Dialect{Comma: '"'}},
+	})
+	var optionErr *json2csv.OptionError
+	if !errors.As(err, &optionErr) || optionErr.Option != "Writer.Dialect" {
+		t.Errorf("Expected *OptionError of Writer.Dialect, but %#v", err)
+	}
+
+	c, err := json2csv.NewConverter(json2csv.Options{MaxMemory: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	b := &bytes.Buffer{}
+
+	err = c.ConvertReader(ctx, b, strings.NewReader(`[{"id": `))
+	var decodeErr *json2csv.DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Errorf("Expected *DecodeError, but %#v", err)
+	}
+
+	for _, in := range []string{`{"id": 1} {"id": 2}`, `{"id": 1}]`} {
+		err = c.ConvertReader(ctx, b, strings.NewReader(in))
+		if !errors.As(err, &decodeErr) {
+			t.Errorf("Expected *DecodeError for %q, but %#v", in, err)
+		}
+	}
+
+	_, err = json2csv.NewConverter(json2csv.Options{Path: "items"})
+	if !errors.As(err, &optionErr) || optionErr.Option != "Path" {
+		t.Errorf("Expected *OptionError of Path, but %#v", err)
+	}
+
+	err = c.ConvertReader(ctx, b, strings.NewReader(`"foo"`))
+	if !errors.Is(err, json2csv.ErrUnsupportedJSON) {
+		t.Errorf("Expected ErrUnsupportedJSON, but %#v", err)
+	}
+
+	err = c.ConvertReader(ctx, b, strings.NewReader(`[{"id": 1, "name": "foo"}]`))
+	var memoryErr *json2csv.MemoryLimitError
+	if !errors.As(err, &memoryErr) {
+		t.Errorf("Expected *MemoryLimitError, but %#v", err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = c.WriteCSV(canceled, b, []json2csv.KeyValue{{"/id": 1}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, but %#v", err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("Expected no output, but %q", b.String())
+	}
+}
+
+func TestConverterWriteRecords(t *testing.T) {
+	c, err := json2csv.NewConverter(json2csv.Options{
+		Writer: json2csv.WriterOptions{QuoteEmpty: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := []json2csv.KeyValue{{"/id": 1, "/name": ""}}
+
+	b := &bytes.Buffer{}
+	format, _ := json2csv.LookupFormat("csv")
+	rw := format(b)
+	if err := c.WriteRecords(context.Background(), rw, rows); err != nil {
+		t.Fatal(err)
+	}
+	rw.Close()
+	expected := "/id,/name\n1,\"\"\n"
+	if b.String() != expected {
+		t.Errorf("Expected %q, but %q", expected, b.String())
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	b.Reset()
+	rw = format(b)
+	err = c.WriteRecords(canceled, rw, rows)
+	rw.Close()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, but %#v", err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("Expected no output, but %q", b.String())
+	}
+}
+
+func TestConverterStreamWriter(t *testing.T) {
+	c, err := json2csv.NewConverter(json2csv.Options{
+		Writer: json2csv.WriterOptions{
+			HeaderStyle: json2csv.DotNotationStyle,
+			Columns:     []string{"/id"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &bytes.Buffer{}
+	sw := c.NewStreamWriter(b)
+	sw.Dropped = func(int, json2csv.KeyValue) error { return nil }
+	for _, kv := range []json2csv.KeyValue{{"/id": 1, "/x": 2}, {"/id": 3}} {
+		if err := sw.WriteRecord(kv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := "id\n1\n3\n"
+	if b.String() != expected {
+		t.Errorf("Expected %q, but %q", expected, b.String())
+	}
+}
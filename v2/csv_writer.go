@@ -0,0 +1,719 @@
+package json2csv
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"unicode/utf8"
+)
+
+// KeyStyle represents the specific style of the key.
+type KeyStyle uint
+
+// Header style
+const (
+	// "/foo/bar/0/baz"
+	JSONPointerStyle KeyStyle = iota
+
+	// "foo/bar/0/baz"
+	SlashStyle
+
+	// "foo.bar.0.baz"
+	DotNotationStyle
+
+	// "foo.bar[0].baz"
+	DotBracketStyle
+)
+
+// TruncationStyle represents how to shorten a header which exceeds the limit.
+type TruncationStyle uint
+
+// Truncation style
+const (
+	// "/foo/bar/baz" -> "/foo/ba"
+	TruncateStyle TruncationStyle = iota
+
+	// "/foo/bar/baz" -> "/_1a2b3c4d" (keeps headers distinguishable)
+	HashSuffixStyle
+)
+
+// ProtectionStyle represents how to protect numeric-looking strings from
+// being converted to numbers by spreadsheets.
+type ProtectionStyle uint
+
+// Protection style
+const (
+	// "01234" -> 01234
+	NoProtection ProtectionStyle = iota
+
+	// "01234" -> "01234"
+	QuoteProtection
+
+	// "01234" -> "=""01234""" (Excel formula which yields a text)
+	FormulaProtection
+)
+
+// defaultCommentPrefix is the prefix of comment lines.
+const defaultCommentPrefix = "# "
+
+// CSVWriter writes CSV data.
+//
+// The fields are written with Comma and UseCRLF of the csv.Writer, and
+// Terminator and Quoting. The csv.Writer must not be replaced; use Reset to
+// switch the output.
+type CSVWriter struct {
+	*csv.Writer
+	HeaderStyle KeyStyle
+	Transpose   bool
+
+	// Terminator is the record terminator (e.g. "\x1e" or "\x00").
+	// If it is set, it is used instead of the line terminator, and line
+	// breaks in fields are written as is regardless of UseCRLF.
+	Terminator string
+
+	// Quoting specifies when fields are quoted.
+	Quoting QuoteStyle
+
+	// QuoteEmpty quotes empty string values ("") to distinguish them from
+	// missing or null values, which are written as unquoted empty fields.
+	QuoteEmpty bool
+
+	// Comments are written before the header, each line prefixed with
+	// CommentPrefix ("# " if empty).
+	Comments      []string
+	CommentPrefix string
+
+	// GroupHeader writes a two-row header: the top-level key in the first
+	// row, and the rest of the path in the second row, e.g. "user" and
+	// "address.city" for "/user/address/city". Top-level values have an
+	// empty second row. The columns keep their order, so consumers can merge
+	// adjacent cells of the same group. Translator, HeaderPrefix,
+	// HeaderSuffix and MaxHeaderLength are not applied.
+	GroupHeader bool
+
+	// Translator translates the header names, e.g. into the language of the
+	// end user. HeaderPrefix, HeaderSuffix and MaxHeaderLength are applied
+	// to the translated names, and the other settings by header names
+	// (PreviousHeader, ColumnsFirst, ColumnQuoting, ColumnTransforms,
+	// ColumnFormats) refer to them.
+	Translator Translator
+
+	// HeaderPrefix and HeaderSuffix are added to every header name,
+	// e.g. "raw_" for loading into a staging table with other sources.
+	HeaderPrefix string
+	HeaderSuffix string
+
+	// MaxHeaderLength is the maximum length of header names in bytes,
+	// including HeaderPrefix and HeaderSuffix. Zero means no limit.
+	MaxHeaderLength int
+
+	// HeaderTruncation specifies how to shorten header names exceeding
+	// MaxHeaderLength.
+	HeaderTruncation TruncationStyle
+
+	// PreviousHeader is the header names of the previous output.
+	// If it is set, the columns keep the previous order and new columns are
+	// appended at the end, so that the layout stays stable across runs.
+	// Previous columns which no longer exist are kept as empty columns.
+	PreviousHeader []string
+
+	// ColumnsFirst is the columns moved to the front in the given order.
+	// The items are header names or keys (JSON Pointers). Other columns keep
+	// their order. Missing columns are ignored.
+	ColumnsFirst []string
+
+	// Columns is the keys (JSON Pointers) of the columns in order, e.g. a
+	// CSVHeader. If it is set, the other keys of the rows are not written,
+	// and PreviousHeader and ColumnsFirst are not applied.
+	Columns []string
+
+	// NumericStrings specifies how to protect string values which look like
+	// numbers with leading zeros or long digit runs (e.g. zip codes, phone
+	// numbers and IDs), which spreadsheets mangle.
+	NumericStrings ProtectionStyle
+
+	// ColumnQuoting overrides Quoting for specific columns.
+	// The keys are header names or keys (JSON Pointers), e.g. always quote
+	// "zip_code" so that spreadsheets keep its leading zeros.
+	ColumnQuoting map[string]QuoteStyle
+
+	// TrimSpace removes the leading and trailing white space of string values.
+	TrimSpace bool
+
+	// CollapseSpace replaces each run of white space (including line breaks)
+	// in string values with a single space.
+	CollapseSpace bool
+
+	// NormalizeUnicode normalizes string values to NFC, so that the same text
+	// composed differently (e.g. "é" as one or two code points) is written
+	// the same.
+	NormalizeUnicode bool
+
+	// ColumnTransforms rewrites the string values of specific columns by the
+	// transforms in order, after TrimSpace, CollapseSpace and
+	// NormalizeUnicode. The keys are header names or keys (JSON Pointers).
+	ColumnTransforms map[string][]Transform
+
+	// ColumnFormats formats the numeric values of specific columns, e.g. as
+	// currency amounts or byte sizes. The keys are header names or keys
+	// (JSON Pointers).
+	ColumnFormats map[string]NumberFormat
+
+	// MaxCellSize is the maximum size of cell values in bytes. Zero means no
+	// limit. Larger values are written to Overflow, and the cell has the
+	// reference token "overflow:ROW:KEY" instead, where ROW is the row number
+	// (starting from 1, excluding the header) and KEY is the JSON Pointer.
+	// If Overflow is nil, larger values are truncated.
+	MaxCellSize int
+
+	// Overflow receives the values exceeding MaxCellSize as JSON lines like
+	// {"row":3,"column":"/payload","value":"..."}.
+	Overflow io.Writer
+
+	// SelfCheck buffers the output and parses it strictly by RFC 4180, and
+	// verifies that it has the records and fields which were written, to
+	// catch broken output before the file ships. WriteCSV returns
+	// *SelfCheckError without writing the output if the check fails.
+	// Comment lines are skipped. StreamWriter doesn't check.
+	SelfCheck bool
+
+	// Parallelism is the number of goroutines formatting and serializing the
+	// rows in chunks, which are written in order. Zero or one writes the rows
+	// sequentially. ColumnTransforms and Overflow are used concurrently then.
+	// Transpose and StreamWriter don't write in parallel.
+	Parallelism int
+
+	// ctx aborts writing with ctx.Err() when it is done (set by Converter)
+	ctx context.Context
+
+	// parsed pointers kept across conversions (see CachePointers)
+	pointerCache pointerCache
+
+	// out writes the fields. It shares the buffer with the csv.Writer.
+	out *Writer
+}
+
+// NewCSVWriter returns new CSVWriter with JSONPointerStyle.
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	out := NewWriter(w)
+	return &CSVWriter{
+		Writer:      csv.NewWriter(out.w),
+		HeaderStyle: JSONPointerStyle,
+		out:         out,
+	}
+}
+
+// SetDialect sets the delimiters and the quoting.
+func (w *CSVWriter) SetDialect(d Dialect) {
+	w.Comma = d.Comma
+	w.Terminator = d.Terminator
+	w.Quoting = d.Quoting
+}
+
+// Reset discards any unflushed data and switches the output to out.
+// The settings are kept.
+func (w *CSVWriter) Reset(out io.Writer) {
+	comma, useCRLF := w.Comma, w.UseCRLF
+	w.out = NewWriter(out)
+	w.Writer = csv.NewWriter(w.out.w)
+	w.Comma, w.UseCRLF = comma, useCRLF
+}
+
+// CachePointers keeps the parsed JSON Pointers of the header across
+// WriteCSV calls, for a writer converting many payloads with Reset.
+func (w *CSVWriter) CachePointers() {
+	if w.pointerCache == nil {
+		w.pointerCache = pointerCache{}
+	}
+}
+
+// writer returns the Writer of the fields with the current settings.
+func (w *CSVWriter) writer() *Writer {
+	w.out.Comma = w.Comma
+	w.out.UseCRLF = w.UseCRLF
+	w.out.Terminator = w.Terminator
+	w.out.Quoting = w.Quoting
+	return w.out
+}
+
+// writeFields writes a record of the formatted fields.
+func (w *CSVWriter) writeFields(record []field) error {
+	if err := w.contextErr(); err != nil {
+		return err
+	}
+	return w.writer().writeFields(record)
+}
+
+// contextErr returns ctx.Err() of the conversion, if any.
+func (w *CSVWriter) contextErr() error {
+	if w.ctx == nil {
+		return nil
+	}
+	return w.ctx.Err()
+}
+
+// WriteCSV writes CSV data.
+func (w *CSVWriter) WriteCSV(results []KeyValue) error {
+	if w.SelfCheck {
+		return w.writeChecked(results)
+	}
+	if w.Transpose {
+		return w.writeTransposedCSV(results)
+	}
+	return w.writeCSV(results)
+}
+
+// WriteCSV writes CSV data.
+func (w *CSVWriter) writeCSV(results []KeyValue) error {
+	keys, header, err := w.columns(results)
+	if err != nil {
+		return err
+	}
+
+	if err := w.writeHeader(keys, header); err != nil {
+		return err
+	}
+
+	format := w.formatter(keys, header)
+	if w.Parallelism > 1 {
+		if err := w.writeParallel(keys, results, format); err != nil {
+			return err
+		}
+	} else {
+		index := newColumnIndex(keys)
+		for n, result := range results {
+			record := index.Record(result, format)
+			if err := w.limitCells(n+1, keys, record); err != nil {
+				return err
+			}
+			if err := w.writeFields(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// WriteCSV writes CSV data which is transposed rows and columns.
+// The formatted cells are buffered by column like TransposeWriter, so they
+// are spooled to a temporary file rather than held in memory.
+func (w *CSVWriter) writeTransposedCSV(results []KeyValue) error {
+	keys, header, err := w.columns(results)
+	if err != nil {
+		return err
+	}
+
+	tw := &TransposeWriter{CSVWriter: w}
+	defer tw.removeSpool()
+	tw.begin(keys, header)
+	for _, result := range results {
+		if err := tw.addRecord(result); err != nil {
+			return err
+		}
+	}
+	return tw.writeColumns()
+}
+
+// writeComments writes Comments.
+func (w *CSVWriter) writeComments() error {
+	prefix := w.CommentPrefix
+	if prefix == "" {
+		prefix = defaultCommentPrefix
+	}
+	for _, comment := range w.Comments {
+		if err := w.writer().WriteComment(prefix, comment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeHeader writes the comments and the header rows.
+func (w *CSVWriter) writeHeader(keys []string, header []string) error {
+	if err := w.writeComments(); err != nil {
+		return err
+	}
+	rows, err := w.headerRows(keys, header)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := w.writer().Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// headerRows returns the header rows, which is the header names, or the
+// groups and the leaves if GroupHeader is set.
+func (w *CSVWriter) headerRows(keys []string, header []string) ([][]string, error) {
+	if !w.GroupHeader {
+		return [][]string{header}, nil
+	}
+
+	groups := make([]string, len(keys))
+	leaves := make([]string, len(keys))
+	for i, key := range keys {
+		if key == "" {
+			// previous column which no longer exists
+			groups[i] = header[i]
+			continue
+		}
+		pointer, err := w.pointerCache.Parse(key)
+		if err != nil {
+			return nil, err
+		}
+		if pointer.Len() == 0 {
+			continue
+		}
+		groups[i] = string(pointer[0])
+		if pointer.Len() > 1 {
+			leaves[i] = styledHeader(w.HeaderStyle, pointers{pointer[1:]})[0]
+		}
+	}
+	return [][]string{groups, leaves}, nil
+}
+
+// formatter returns the formatFunc for the columns.
+func (w *CSVWriter) formatter(keys []string, header []string) formatFunc {
+	quoting := w.columnQuoting(keys, header)
+	formats := w.columnFormats(keys, header)
+	transform := w.transformer(keys, header)
+	return func(column int, value interface{}) field {
+		if str, ok := value.(string); ok {
+			value = transform(column, str)
+		}
+		s := toString(value)
+		if formats[column] != nil {
+			if formatted, ok := formats[column].Format(value); ok {
+				s = formatted
+			}
+		}
+		f := field{
+			value:   s,
+			quote:   w.QuoteEmpty && s == "",
+			quoting: quoting[column],
+		}
+		if str, ok := value.(string); ok && w.NumericStrings != NoProtection && isNumericID(str) {
+			switch w.NumericStrings {
+			case QuoteProtection:
+				f.quote = true
+			case FormulaProtection:
+				f.value = `="` + str + `"`
+			}
+		}
+		return f
+	}
+}
+
+// transformer returns the function which applies TrimSpace, CollapseSpace,
+// NormalizeUnicode and ColumnTransforms to a string value of the column.
+func (w *CSVWriter) transformer(keys []string, header []string) func(column int, s string) string {
+	transforms := w.columnTransforms(keys, header)
+	return func(column int, str string) string {
+		if w.normalizesStrings() {
+			str = w.normalizeString(str)
+		}
+		for _, t := range transforms[column] {
+			str = t(str)
+		}
+		return str
+	}
+}
+
+// overflowRecord is a line of Overflow.
+type overflowRecord struct {
+	Row    int    `json:"row"`
+	Column string `json:"column"`
+	Value  string `json:"value"`
+}
+
+// limitCells applies MaxCellSize to the fields of the row.
+func (w *CSVWriter) limitCells(row int, keys []string, record []field) error {
+	if w.MaxCellSize <= 0 {
+		return nil
+	}
+	for i := range record {
+		if err := w.limitCell(row, keys[i], &record[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// limitCell moves the value exceeding MaxCellSize to Overflow and replaces
+// it with the reference token, or truncates it if Overflow is nil.
+func (w *CSVWriter) limitCell(row int, key string, f *field) error {
+	if w.MaxCellSize <= 0 || len(f.value) <= w.MaxCellSize {
+		return nil
+	}
+	if w.Overflow == nil {
+		f.value = cutString(f.value, w.MaxCellSize)
+		return nil
+	}
+
+	line, err := json.Marshal(overflowRecord{row, key, f.value})
+	if err != nil {
+		return err
+	}
+	if _, err := w.Overflow.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	f.value = fmt.Sprintf("overflow:%d:%s", row, key)
+	return nil
+}
+
+// columnQuoting returns the quoting style of each column by ColumnQuoting.
+// Header names take precedence over keys. Columns without the setting are nil.
+func (w *CSVWriter) columnQuoting(keys []string, header []string) []*QuoteStyle {
+	quoting := make([]*QuoteStyle, len(keys))
+	if len(w.ColumnQuoting) == 0 {
+		return quoting
+	}
+	for i := range keys {
+		if style, ok := w.ColumnQuoting[header[i]]; ok {
+			quoting[i] = &style
+		} else if style, ok := w.ColumnQuoting[keys[i]]; ok && keys[i] != "" {
+			quoting[i] = &style
+		}
+	}
+	return quoting
+}
+
+// columnFormats returns the NumberFormat of each column by ColumnFormats.
+// Header names take precedence over keys. Columns without the setting are nil.
+func (w *CSVWriter) columnFormats(keys []string, header []string) []*NumberFormat {
+	formats := make([]*NumberFormat, len(keys))
+	if len(w.ColumnFormats) == 0 {
+		return formats
+	}
+	for i := range keys {
+		if f, ok := w.ColumnFormats[header[i]]; ok {
+			formats[i] = &f
+		} else if f, ok := w.ColumnFormats[keys[i]]; ok && keys[i] != "" {
+			formats[i] = &f
+		}
+	}
+	return formats
+}
+
+// WriteHeaderMapping writes the mapping from each key (JSON Pointer) to the
+// header name as CSV, so that renamed or truncated headers can be traced back
+// to the source paths.
+func (w *CSVWriter) WriteHeaderMapping(out io.Writer, results []KeyValue) error {
+	keys, header, err := w.columns(results)
+	if err != nil {
+		return err
+	}
+
+	mw := NewWriter(out)
+	if err := mw.Write([]string{"pointer", "column"}); err != nil {
+		return err
+	}
+	for i, key := range keys {
+		if err := mw.Write([]string{key, header[i]}); err != nil {
+			return err
+		}
+	}
+
+	mw.Flush()
+	return mw.Error()
+}
+
+// Header returns the keys (JSON Pointers) and the header names of the columns
+// written by WriteCSV.
+func (w *CSVWriter) Header(results []KeyValue) (keys []string, header []string, err error) {
+	return w.columns(results)
+}
+
+// columns returns the sorted keys and the corresponding header names, or
+// the columns of Columns.
+func (w *CSVWriter) columns(results []KeyValue) (keys []string, header []string, err error) {
+	if len(w.Columns) > 0 {
+		return w.fixedColumns()
+	}
+	pts, err := allPointers(results, w.pointerCache)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Sort(pts)
+	keys, header = w.arrangeColumns(pts.Strings(), w.getHeader(pts))
+	return keys, header, nil
+}
+
+// fixedColumns returns the columns of Columns.
+func (w *CSVWriter) fixedColumns() (keys []string, header []string, err error) {
+	pts := make(pointers, 0, len(w.Columns))
+	for _, key := range w.Columns {
+		pointer, err := w.pointerCache.Parse(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		pts = append(pts, pointer)
+	}
+	return pts.Strings(), w.getHeader(pts), nil
+}
+
+// arrangeColumns reorders the columns according to PreviousHeader and
+// ColumnsFirst.
+func (w *CSVWriter) arrangeColumns(keys []string, header []string) ([]string, []string) {
+	keys, header = w.keepPreviousColumns(keys, header)
+	return w.moveColumnsFirst(keys, header)
+}
+
+// keepPreviousColumns reorders the columns according to PreviousHeader.
+// Previous columns which no longer exist have an empty key, which never
+// appears in the results.
+func (w *CSVWriter) keepPreviousColumns(keys []string, header []string) ([]string, []string) {
+	if len(w.PreviousHeader) == 0 {
+		return keys, header
+	}
+
+	positions := make(map[string][]int, len(header))
+	for i, name := range header {
+		positions[name] = append(positions[name], i)
+	}
+
+	used := make([]bool, len(keys))
+	newKeys := make([]string, 0, len(keys)+len(w.PreviousHeader))
+	newHeader := make([]string, 0, len(keys)+len(w.PreviousHeader))
+	for _, name := range w.PreviousHeader {
+		if p := positions[name]; len(p) > 0 {
+			i := p[0]
+			positions[name] = p[1:]
+			used[i] = true
+			newKeys = append(newKeys, keys[i])
+		} else {
+			newKeys = append(newKeys, "")
+		}
+		newHeader = append(newHeader, name)
+	}
+	for i := range keys {
+		if !used[i] {
+			newKeys = append(newKeys, keys[i])
+			newHeader = append(newHeader, header[i])
+		}
+	}
+	return newKeys, newHeader
+}
+
+// moveColumnsFirst moves the columns in ColumnsFirst to the front.
+func (w *CSVWriter) moveColumnsFirst(keys []string, header []string) ([]string, []string) {
+	if len(w.ColumnsFirst) == 0 {
+		return keys, header
+	}
+
+	used := make([]bool, len(keys))
+	newKeys := make([]string, 0, len(keys))
+	newHeader := make([]string, 0, len(keys))
+	for _, name := range w.ColumnsFirst {
+		for i := range keys {
+			if !used[i] && (header[i] == name || (keys[i] != "" && keys[i] == name)) {
+				used[i] = true
+				newKeys = append(newKeys, keys[i])
+				newHeader = append(newHeader, header[i])
+				break
+			}
+		}
+	}
+	for i := range keys {
+		if !used[i] {
+			newKeys = append(newKeys, keys[i])
+			newHeader = append(newHeader, header[i])
+		}
+	}
+	return newKeys, newHeader
+}
+
+func allPointers(results []KeyValue, cache pointerCache) (pointers pointers, err error) {
+	set := make(map[string]bool, 0)
+	for _, result := range results {
+		for key := range result {
+			if !set[key] {
+				set[key] = true
+				pointer, err := cache.Parse(key)
+				if err != nil {
+					return nil, err
+				}
+				pointers = append(pointers, pointer)
+			}
+		}
+	}
+	return
+}
+
+func (w *CSVWriter) getHeader(pointers pointers) []string {
+	header := w.styledHeader(pointers)
+	translateHeader(w.Translator, pointers.Strings(), header)
+	if w.HeaderPrefix != "" || w.HeaderSuffix != "" {
+		for i, name := range header {
+			header[i] = w.HeaderPrefix + name + w.HeaderSuffix
+		}
+	}
+	if w.MaxHeaderLength > 0 {
+		for i, name := range header {
+			header[i] = truncateHeader(name, w.MaxHeaderLength, w.HeaderTruncation)
+		}
+	}
+	return header
+}
+
+func (w *CSVWriter) styledHeader(pointers pointers) []string {
+	return styledHeader(w.HeaderStyle, pointers)
+}
+
+func styledHeader(style KeyStyle, pointers pointers) []string {
+	switch style {
+	case JSONPointerStyle:
+		return pointers.Strings()
+	case SlashStyle:
+		return pointers.Slashes()
+	case DotNotationStyle:
+		return pointers.DotNotations(false)
+	case DotBracketStyle:
+		return pointers.DotNotations(true)
+	default:
+		return pointers.Strings()
+	}
+}
+
+func truncateHeader(name string, limit int, style TruncationStyle) string {
+	if len(name) <= limit {
+		return name
+	}
+
+	switch style {
+	case HashSuffixStyle:
+		h := fnv.New32a()
+		h.Write([]byte(name))
+		suffix := fmt.Sprintf("_%08x", h.Sum32())
+		if limit <= len(suffix) {
+			return suffix[len(suffix)-limit:]
+		}
+		return cutString(name, limit-len(suffix)) + suffix
+	default:
+		return cutString(name, limit)
+	}
+}
+
+// cutString cuts s to at most n bytes without splitting a UTF-8 sequence.
+func cutString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
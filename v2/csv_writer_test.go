@@ -0,0 +1,767 @@
+package json2csv_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yukithm/json2csv/v2"
+)
+
+func TestKeyWithTrailingSpace(t *testing.T) {
+	b := &bytes.Buffer{}
+	wr := json2csv.NewCSVWriter(b)
+	responses := []map[string]interface{}{
+		{
+			" A":  1,
+			"B ":  "foo",
+			"C  ": "FOO",
+		},
+		{
+			" A":  2,
+			"B ":  "bar",
+			"C  ": "BAR",
+		},
+	}
+
+	csvContent, err := json2csv.Flatten(responses, json2csv.FlattenOptions{}) // csvContent seems to be complete!
+	if err != nil {
+		t.Fatal(err)
+	}
+	wr.WriteCSV(csvContent)
+	wr.Flush()
+
+	got := b.String()
+	want := `/ A,/B ,/C  
+1,foo,FOO
+2,bar,BAR
+`
+
+	if got != want {
+		t.Errorf("Expected %v, but %v", want, got)
+	}
+}
+
+func TestEmbeddedCSVWriter(t *testing.T) {
+	b := &bytes.Buffer{}
+	wr := json2csv.NewCSVWriter(b)
+	var cw *csv.Writer = wr.Writer
+	cw.Comma = ';'
+	if err := cw.Write([]string{"x", "y"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := wr.WriteCSV([]json2csv.KeyValue{{"/a": 1, "/b": "c;d"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "x;y\n/a;/b\n1;\"c;d\"\n"
+	if got := b.String(); got != want {
+		t.Errorf("Expected %q, but %q", want, got)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	results := []json2csv.KeyValue{
+		{"/id": 1, "/name": "foo", "/x": true},
+		{"/id": 2},
+	}
+	header, err := json2csv.NewCSVHeaderFromStrings([]string{"/name", "/id", "/email"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &bytes.Buffer{}
+	wr := json2csv.NewCSVWriter(b)
+	wr.HeaderStyle = json2csv.DotNotationStyle
+	wr.Columns = header
+	if err := wr.WriteCSV(results); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "name,id,email\nfoo,1,\n,2,\n"
+	if got := b.String(); got != want {
+		t.Errorf("Expected %q, but %q", want, got)
+	}
+}
+
+func TestMaxHeaderLength(t *testing.T) {
+	responses := []map[string]interface{}{
+		{
+			"id": 1,
+			"very_long_column_name": map[string]interface{}{
+				"nested_a": "foo",
+				"nested_b": "bar",
+			},
+		},
+	}
+	results, err := json2csv.Flatten(responses, json2csv.FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		style json2csv.TruncationStyle
+		want  string
+	}{
+		{json2csv.TruncateStyle, "/id,/very_long_colu,/very_long_colu\n1,foo,bar\n"},
+		{json2csv.HashSuffixStyle, "/id,/very__6aad9ad4,/very__6dad9f8d\n1,foo,bar\n"},
+	}
+	for caseIndex, testCase := range testCases {
+		b := &bytes.Buffer{}
+		wr := json2csv.NewCSVWriter(b)
+		wr.MaxHeaderLength = 15
+		wr.HeaderTruncation = testCase.style
+		if err := wr.WriteCSV(results); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, got)
+		}
+	}
+}
+
+func TestWriteHeaderMapping(t *testing.T) {
+	results := []json2csv.KeyValue{
+		{"/id": 1, "/user/name": "foo"},
+	}
+
+	b := &bytes.Buffer{}
+	wr := json2csv.NewCSVWriter(&bytes.Buffer{})
+	wr.HeaderStyle = json2csv.DotNotationStyle
+	if err := wr.WriteHeaderMapping(b, results); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "pointer,column\n/id,id\n/user/name,user.name\n"
+	if got := b.String(); got != want {
+		t.Errorf("Expected %q, but %q", want, got)
+	}
+}
+
+// wideSchemaResults returns 1000 rows of 5000 columns, each row has 50 of them.
+func wideSchemaResults() []json2csv.KeyValue {
+	results := make([]json2csv.KeyValue, 1000)
+	for i := range results {
+		kv := make(json2csv.KeyValue, 50)
+		for j := 0; j < 50; j++ {
+			kv[fmt.Sprintf("/group%d/field%d", (i+j)%100, j)] = i
+		}
+		results[i] = kv
+	}
+	return results
+}
+
+func BenchmarkWriteCSVWideSchema(b *testing.B) {
+	results := wideSchemaResults()
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		wr := json2csv.NewCSVWriter(ioutil.Discard)
+		if err := wr.WriteCSV(results); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWriteCSVWideSchemaParallel(b *testing.B) {
+	results := wideSchemaResults()
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		wr := json2csv.NewCSVWriter(ioutil.Discard)
+		wr.Parallelism = 4
+		if err := wr.WriteCSV(results); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWriteTransposedCSVWideSchema(b *testing.B) {
+	results := wideSchemaResults()
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		wr := json2csv.NewCSVWriter(ioutil.Discard)
+		wr.Transpose = true
+		if err := wr.WriteCSV(results); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestTranspose(t *testing.T) {
+	results := []json2csv.KeyValue{
+		{"/id": 1, "/name": "foo", "/favorites/color": "red"},
+		{"/id": 2, "/name": "bar"},
+		{"/id": 3, "/favorites/color": "yellow"},
+	}
+
+	b := &bytes.Buffer{}
+	wr := json2csv.NewCSVWriter(b)
+	wr.Transpose = true
+	if err := wr.WriteCSV(results); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "/id,1,2,3\n/name,foo,bar,\n/favorites/color,red,,yellow\n"
+	if got := b.String(); got != want {
+		t.Errorf("Expected %q, but %q", want, got)
+	}
+}
+
+func TestPreviousHeader(t *testing.T) {
+	results := []json2csv.KeyValue{
+		{"/id": 1, "/name": "foo", "/age": 20, "/email": "foo@example.com"},
+	}
+
+	b := &bytes.Buffer{}
+	wr := json2csv.NewCSVWriter(b)
+	wr.HeaderStyle = json2csv.DotNotationStyle
+	wr.PreviousHeader = []string{"name", "removed", "id"}
+	if err := wr.WriteCSV(results); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "name,removed,id,age,email\nfoo,,1,20,foo@example.com\n"
+	if got := b.String(); got != want {
+		t.Errorf("Expected %q, but %q", want, got)
+	}
+}
+
+func TestQuoteEmpty(t *testing.T) {
+	obj := []interface{}{
+		map[string]interface{}{"id": 1, "name": "", "note": nil},
+		map[string]interface{}{"id": 2, "name": "foo", "note": "bar"},
+	}
+	results, err := json2csv.Flatten(obj, json2csv.FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &bytes.Buffer{}
+	wr := json2csv.NewCSVWriter(b)
+	wr.QuoteEmpty = true
+	if err := wr.WriteCSV(results); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "/id,/name,/note\n1,\"\",\n2,foo,bar\n"
+	if got := b.String(); got != want {
+		t.Errorf("Expected %q, but %q", want, got)
+	}
+}
+
+func TestColumnQuoting(t *testing.T) {
+	obj := []interface{}{
+		map[string]interface{}{"zip": "01234", "note": "a,b", "name": "foo"},
+	}
+	results, err := json2csv.Flatten(obj, json2csv.FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &bytes.Buffer{}
+	wr := json2csv.NewCSVWriter(b)
+	wr.HeaderStyle = json2csv.DotNotationStyle
+	wr.ColumnQuoting = map[string]json2csv.QuoteStyle{
+		"zip":   json2csv.QuoteAll,
+		"/note": json2csv.QuoteNone,
+	}
+	if err := wr.WriteCSV(results); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "name,note,zip\nfoo,\"a,b\",\"01234\"\n"
+	if got := b.String(); got != want {
+		t.Errorf("Expected %q, but %q", want, got)
+	}
+}
+
+func TestNumericStrings(t *testing.T) {
+	obj := []interface{}{
+		map[string]interface{}{"a": "01234", "b": "1234", "c": "12345678901234567890", "d": 123},
+	}
+	results, err := json2csv.Flatten(obj, json2csv.FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		style json2csv.ProtectionStyle
+		want  string
+	}{
+		{json2csv.NoProtection, "/a,/b,/c,/d\n01234,1234,12345678901234567890,123\n"},
+		{json2csv.QuoteProtection, "/a,/b,/c,/d\n\"01234\",1234,\"12345678901234567890\",123\n"},
+		{json2csv.FormulaProtection, "/a,/b,/c,/d\n\"=\"\"01234\"\"\",1234,\"=\"\"12345678901234567890\"\"\",123\n"},
+	}
+
+	for caseIndex, testCase := range testCases {
+		b := &bytes.Buffer{}
+		wr := json2csv.NewCSVWriter(b)
+		wr.NumericStrings = testCase.style
+		if err := wr.WriteCSV(results); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, got)
+		}
+	}
+}
+
+func TestColumnsFirst(t *testing.T) {
+	obj := []interface{}{
+		map[string]interface{}{"a": 1, "b": 2, "c": 3, "d": 4},
+	}
+	results, err := json2csv.Flatten(obj, json2csv.FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		first []string
+		want  string
+	}{
+		{nil, "a,b,c,d\n1,2,3,4\n"},
+		{[]string{"c", "a"}, "c,a,b,d\n3,1,2,4\n"},
+		{[]string{"/d", "x", "b"}, "d,b,a,c\n4,2,1,3\n"},
+	}
+
+	for caseIndex, testCase := range testCases {
+		b := &bytes.Buffer{}
+		wr := json2csv.NewCSVWriter(b)
+		wr.HeaderStyle = json2csv.DotNotationStyle
+		wr.ColumnsFirst = testCase.first
+		if err := wr.WriteCSV(results); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, got)
+		}
+	}
+}
+
+func TestHeaderPrefixSuffix(t *testing.T) {
+	obj := []interface{}{
+		map[string]interface{}{"a": 1, "b": map[string]interface{}{"c": 2}},
+	}
+	results, err := json2csv.Flatten(obj, json2csv.FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &bytes.Buffer{}
+	wr := json2csv.NewCSVWriter(b)
+	wr.HeaderStyle = json2csv.DotNotationStyle
+	wr.HeaderPrefix = "raw_"
+	wr.HeaderSuffix = "_v1"
+	if err := wr.WriteCSV(results); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "raw_a_v1,raw_b.c_v1\n1,2\n"
+	if got := b.String(); got != want {
+		t.Errorf("Expected %q, but %q", want, got)
+	}
+}
+
+func TestGroupHeader(t *testing.T) {
+	obj := []interface{}{
+		map[string]interface{}{
+			"id":   1,
+			"user": map[string]interface{}{"name": "foo", "address": map[string]interface{}{"city": "bar"}},
+		},
+	}
+	results, err := json2csv.Flatten(obj, json2csv.FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		transpose bool
+		want      string
+	}{
+		{false, "id,user,user\n,name,address.city\n1,foo,bar\n"},
+		{true, "id,,1\nuser,name,foo\nuser,address.city,bar\n"},
+	}
+
+	for caseIndex, testCase := range testCases {
+		b := &bytes.Buffer{}
+		wr := json2csv.NewCSVWriter(b)
+		wr.HeaderStyle = json2csv.DotNotationStyle
+		wr.GroupHeader = true
+		wr.Transpose = testCase.transpose
+		if err := wr.WriteCSV(results); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, got)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	obj := []interface{}{
+		map[string]interface{}{"a": 1},
+	}
+	results, err := json2csv.Flatten(obj, json2csv.FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		prefix string
+		want   string
+	}{
+		{"", "# foo\n# bar\n# baz\n/a\n1\n"},
+		{"--", "--foo\n--bar\n--baz\n/a\n1\n"},
+	}
+
+	for caseIndex, testCase := range testCases {
+		b := &bytes.Buffer{}
+		wr := json2csv.NewCSVWriter(b)
+		wr.Comments = []string{"foo", "bar\nbaz"}
+		wr.CommentPrefix = testCase.prefix
+		if err := wr.WriteCSV(results); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, got)
+		}
+	}
+}
+
+func TestHeader(t *testing.T) {
+	obj := []interface{}{
+		map[string]interface{}{"b": 1, "a": map[string]interface{}{"c": 2}},
+	}
+	results, err := json2csv.Flatten(obj, json2csv.FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wr := json2csv.NewCSVWriter(&bytes.Buffer{})
+	wr.HeaderStyle = json2csv.DotNotationStyle
+	keys, header, err := wr.Header(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/b", "/a/c"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Expected %v, but %v", want, keys)
+	}
+	if want := []string{"b", "a.c"}; !reflect.DeepEqual(header, want) {
+		t.Errorf("Expected %v, but %v", want, header)
+	}
+}
+
+func TestMaxCellSize(t *testing.T) {
+	obj := []interface{}{
+		map[string]interface{}{"id": 1, "body": "short"},
+		map[string]interface{}{"id": 2, "body": "very long value"},
+	}
+	results, err := json2csv.Flatten(obj, json2csv.FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		transpose    bool
+		overflow     bool
+		want         string
+		wantOverflow string
+	}{
+		{false, true, "/body,/id\nshort,1\noverflow:2:/body,2\n", `{"row":2,"column":"/body","value":"very long value"}` + "\n"},
+		{true, true, "/body,short,overflow:2:/body\n/id,1,2\n", `{"row":2,"column":"/body","value":"very long value"}` + "\n"},
+		{false, false, "/body,/id\nshort,1\nvery l,2\n", ""},
+	}
+
+	for caseIndex, testCase := range testCases {
+		b := &bytes.Buffer{}
+		overflow := &bytes.Buffer{}
+		wr := json2csv.NewCSVWriter(b)
+		wr.Transpose = testCase.transpose
+		wr.MaxCellSize = 6
+		if testCase.overflow {
+			wr.Overflow = overflow
+		}
+		if err := wr.WriteCSV(results); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, got)
+		}
+		if got := overflow.String(); got != testCase.wantOverflow {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.wantOverflow, got)
+		}
+	}
+}
+
+func TestTranslator(t *testing.T) {
+	obj := []interface{}{
+		map[string]interface{}{"amount": 1, "user": map[string]interface{}{"name": "foo"}, "id": 2},
+	}
+	results, err := json2csv.Flatten(obj, json2csv.FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	translations := json2csv.Translations{
+		"fr": {"/amount": "Montant", "user.name": "Nom"},
+	}
+	fr, ok := translations.Lookup("fr-CA")
+	if !ok {
+		t.Fatal("Expected translations of fr for fr-CA")
+	}
+	if _, ok := translations.Lookup("de"); ok {
+		t.Error("Expected no translations of de")
+	}
+
+	testCases := []struct {
+		translator json2csv.Translator
+		prefix     string
+		want       string
+	}{
+		{fr, "", "Montant,id,Nom\n1,2,foo\n"},
+		{fr, "x_", "x_Montant,x_id,x_Nom\n1,2,foo\n"},
+		{json2csv.TranslatorFunc(func(key, name string) (string, bool) {
+			return strings.ToUpper(name), key != "/id"
+		}), "", "AMOUNT,id,USER.NAME\n1,2,foo\n"},
+	}
+
+	for caseIndex, testCase := range testCases {
+		b := &bytes.Buffer{}
+		wr := json2csv.NewCSVWriter(b)
+		wr.HeaderStyle = json2csv.DotNotationStyle
+		wr.Translator = testCase.translator
+		wr.HeaderPrefix = testCase.prefix
+		if err := wr.WriteCSV(results); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, got)
+		}
+	}
+}
+
+func TestColumnFormats(t *testing.T) {
+	obj := []interface{}{
+		map[string]interface{}{"price": json.Number("1234"), "size": json.Number("2500000"), "note": "x"},
+		map[string]interface{}{"price": "free", "size": json.Number("999"), "note": json.Number("5")},
+	}
+	results, err := json2csv.Flatten(obj, json2csv.FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &bytes.Buffer{}
+	wr := json2csv.NewCSVWriter(b)
+	wr.HeaderStyle = json2csv.DotNotationStyle
+	wr.ColumnFormats = map[string]json2csv.NumberFormat{
+		"price": {Currency: "$", MinorUnits: 2, Precision: 2},
+		"/size": {Unit: "auto", Precision: 1},
+	}
+	if err := wr.WriteCSV(results); err != nil {
+		t.Fatal(err)
+	}
+	expected := "note,price,size\nx,$12.34,2.5 MB\n5,free,999 B\n"
+	if actual := b.String(); actual != expected {
+		t.Errorf("Expected %q, but %q", expected, actual)
+	}
+}
+
+func TestColumnStats(t *testing.T) {
+	obj := []interface{}{
+		map[string]interface{}{"id": 1, "type": "click", "note": ""},
+		map[string]interface{}{"id": 2, "type": "view"},
+		map[string]interface{}{"id": 10, "type": "click", "note": "hello"},
+		map[string]interface{}{"id": 11, "type": "click"},
+	}
+	results, err := json2csv.Flatten(obj, json2csv.FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wr := json2csv.NewCSVWriter(&bytes.Buffer{})
+	wr.HeaderStyle = json2csv.DotNotationStyle
+	actual, err := wr.ColumnStats(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []json2csv.ColumnStats{
+		{Key: "/id", Name: "id", Values: 4, Cardinality: 4, AvgWidth: 1.5, MaxWidth: 2},
+		{Key: "/note", Name: "note", Values: 1, Cardinality: 1, AvgWidth: 1.25, MaxWidth: 5},
+		{Key: "/type", Name: "type", Values: 4, Cardinality: 2, AvgWidth: 4.75, MaxWidth: 5},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %+v, but %+v", expected, actual)
+	}
+}
+
+func TestNormalizeStrings(t *testing.T) {
+	obj := []interface{}{
+		map[string]interface{}{"a": "  foo \t bar\n", "b": "Cafe\u0301", "c": json.Number("1")},
+	}
+	results, err := json2csv.Flatten(obj, json2csv.FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		trim, collapse, nfc bool
+		expected            string
+	}{
+		{false, false, false, "/a,/b,/c\n\"  foo \t bar\n\",Cafe\u0301,1\n"},
+		{true, false, false, "/a,/b,/c\nfoo \t bar,Cafe\u0301,1\n"},
+		{false, true, false, "/a,/b,/c\n\" foo bar \",Cafe\u0301,1\n"},
+		{true, true, true, "/a,/b,/c\nfoo bar,Caf\u00e9,1\n"},
+	}
+
+	for caseIndex, testCase := range testCases {
+		b := &bytes.Buffer{}
+		wr := json2csv.NewCSVWriter(b)
+		wr.TrimSpace = testCase.trim
+		wr.CollapseSpace = testCase.collapse
+		wr.NormalizeUnicode = testCase.nfc
+		if err := wr.WriteCSV(results); err != nil {
+			t.Fatal(err)
+		}
+		if actual := b.String(); actual != testCase.expected {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.expected, actual)
+		}
+	}
+}
+
+func TestColumnTransforms(t *testing.T) {
+	obj := []interface{}{
+		map[string]interface{}{"city": " São Paulo ", "code": "br", "n": json.Number("1")},
+	}
+	results, err := json2csv.Flatten(obj, json2csv.FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &bytes.Buffer{}
+	wr := json2csv.NewCSVWriter(b)
+	wr.HeaderStyle = json2csv.DotNotationStyle
+	wr.TrimSpace = true
+	wr.ColumnTransforms = map[string][]json2csv.Transform{
+		"city":  {json2csv.StripAccents, json2csv.LowerCase},
+		"/code": {json2csv.UpperCase},
+		"n":     {json2csv.UpperCase},
+	}
+	if err := wr.WriteCSV(results); err != nil {
+		t.Fatal(err)
+	}
+	expected := "city,code,n\nsao paulo,BR,1\n"
+	if actual := b.String(); actual != expected {
+		t.Errorf("Expected %q, but %q", expected, actual)
+	}
+}
+
+func TestWriteRecords(t *testing.T) {
+	obj := []interface{}{
+		map[string]interface{}{"id": 1, "name": " Alice\tSmith "},
+		map[string]interface{}{"id": 2},
+	}
+	results, err := json2csv.Flatten(obj, json2csv.FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &bytes.Buffer{}
+	wr := json2csv.NewCSVWriter(&bytes.Buffer{})
+	wr.HeaderStyle = json2csv.DotNotationStyle
+	wr.TrimSpace = true
+	format, ok := json2csv.LookupFormat("tsv")
+	if !ok {
+		t.Fatal("tsv format not found")
+	}
+	rw := format(b)
+	if err := wr.WriteRecords(rw, results); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expected := "id\tname\n1\t\"Alice\tSmith\"\n2\t\n"
+	if b.String() != expected {
+		t.Errorf("Expected %q, but %q", expected, b.String())
+	}
+}
+
+func TestWriteRecordsDelimitedFormats(t *testing.T) {
+	results := []json2csv.KeyValue{
+		{"/id": 1, "/name": "foo"},
+		{"/id": 2, "/name": ""},
+	}
+
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		{"csv", "# source: test\n/id,/name\n1,foo\n2,\"\"\n"},
+		{"tsv", "# source: test\n/id\t/name\n1\tfoo\n2\t\"\"\n"},
+	}
+
+	for caseIndex, testCase := range testCases {
+		wr := json2csv.NewCSVWriter(&bytes.Buffer{})
+		wr.Comma = ';' // the dialect of the format is used
+		wr.QuoteEmpty = true
+		wr.Comments = []string{"source: test"}
+
+		b := &bytes.Buffer{}
+		format, _ := json2csv.LookupFormat(testCase.format)
+		rw := format(b)
+		if _, ok := rw.(json2csv.DelimitedRecordWriter); !ok {
+			t.Fatalf("%d: Expected DelimitedRecordWriter, but %T", caseIndex, rw)
+		}
+		if err := wr.WriteRecords(rw, results); err != nil {
+			t.Fatal(err)
+		}
+		if err := rw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != testCase.expected {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.expected, b.String())
+		}
+	}
+}
+
+func TestParallelism(t *testing.T) {
+	results := make([]json2csv.KeyValue, 3000)
+	for i := range results {
+		results[i] = json2csv.KeyValue{
+			"/id":                    i,
+			fmt.Sprintf("/k%d", i%7): "",
+			"/payload":               strings.Repeat("x", i%13),
+		}
+	}
+
+	write := func(parallelism int) (string, string) {
+		b := &bytes.Buffer{}
+		overflow := &bytes.Buffer{}
+		wr := json2csv.NewCSVWriter(b)
+		wr.QuoteEmpty = true
+		wr.MaxCellSize = 10
+		wr.Overflow = overflow
+		wr.Parallelism = parallelism
+		if err := wr.WriteCSV(results); err != nil {
+			t.Fatal(err)
+		}
+		return b.String(), overflow.String()
+	}
+	expected, expectedOverflow := write(1)
+	for _, parallelism := range []int{2, 4} {
+		actual, actualOverflow := write(parallelism)
+		if actual != expected {
+			t.Errorf("%d: Expected the same output as sequential, but it differs", parallelism)
+		}
+		if actualOverflow != expectedOverflow {
+			t.Errorf("%d: Expected the same overflow as sequential, but it differs", parallelism)
+		}
+	}
+}
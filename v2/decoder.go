@@ -0,0 +1,75 @@
+package json2csv
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Decoder reads JSON values from an input stream.
+//
+// Decode returns io.EOF when there are no more values, i.e. only whitespace
+// is left.
+// Numbers should be decoded as json.Number to keep their precision.
+type Decoder interface {
+	Decode() (interface{}, error)
+}
+
+// DecoderFactory creates a Decoder which reads from r.
+type DecoderFactory func(r io.Reader) Decoder
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]DecoderFactory{
+		"std": NewStdDecoder,
+	}
+)
+
+// RegisterDecoder makes a decoder backend available by the name.
+// It replaces the backend which has the same name.
+func RegisterDecoder(name string, factory DecoderFactory) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[name] = factory
+}
+
+// LookupDecoder returns the decoder backend registered by the name.
+func LookupDecoder(name string) (DecoderFactory, bool) {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	factory, ok := decoders[name]
+	return factory, ok
+}
+
+// DecoderNames returns the sorted names of the registered decoder backends.
+func DecoderNames() []string {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	names := make([]string, 0, len(decoders))
+	for name := range decoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type stdDecoder struct {
+	*json.Decoder
+}
+
+// NewStdDecoder returns a Decoder using encoding/json.
+// It is registered as "std".
+func NewStdDecoder(r io.Reader) Decoder {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	return &stdDecoder{d}
+}
+
+func (d *stdDecoder) Decode() (interface{}, error) {
+	var v interface{}
+	if err := d.Decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,50 @@
+package json2csv
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStdDecoder(t *testing.T) {
+	factory, ok := LookupDecoder("std")
+	if !ok {
+		t.Fatal("std decoder is not registered")
+	}
+
+	d := factory(strings.NewReader(`{"a": 1.50} [2]`))
+	expected := []interface{}{
+		map[string]interface{}{"a": json.Number("1.50")},
+		[]interface{}{json.Number("2")},
+	}
+	for i, want := range expected {
+		got, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: Expected %#v, but %#v", i, want, got)
+		}
+	}
+	if _, err := d.Decode(); err != io.EOF {
+		t.Errorf("Expected EOF, but %v", err)
+	}
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	RegisterDecoder("test", NewStdDecoder)
+	defer func() {
+		decodersMu.Lock()
+		delete(decoders, "test")
+		decodersMu.Unlock()
+	}()
+
+	if _, ok := LookupDecoder("test"); !ok {
+		t.Error("test decoder is not registered")
+	}
+	if names := DecoderNames(); !reflect.DeepEqual(names, []string{"std", "test"}) {
+		t.Errorf("Unexpected names %v", names)
+	}
+}
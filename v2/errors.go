@@ -0,0 +1,69 @@
+package json2csv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsupportedJSON is returned when the JSON is neither an object nor an
+// array.
+var ErrUnsupportedJSON = errors.New("Unsupported JSON structure.")
+
+// MemoryLimitError is returned when the flattened rows exceed
+// Options.MaxMemory.
+type MemoryLimitError struct {
+	Limit int64 // Options.MaxMemory
+	Used  int64 // approximate size of the rows when the limit is exceeded
+	Rows  int   // number of rows when the limit is exceeded
+}
+
+func (e *MemoryLimitError) Error() string {
+	return fmt.Sprintf("Memory limit exceeded: %d rows use about %d bytes (limit %d bytes)", e.Rows, e.Used, e.Limit)
+}
+
+// DroppedKeysError is returned by StreamWriter when a record has keys which
+// are not in the header, and StreamWriter.Dropped is nil.
+type DroppedKeysError struct {
+	Row  int      // row number, starting at 1
+	Keys []string // sorted keys which are not in the header
+}
+
+func (e *DroppedKeysError) Error() string {
+	return fmt.Sprintf("Keys not in the header at row %d: %s", e.Row, strings.Join(e.Keys, ", "))
+}
+
+// XLSXLimitError is returned by XLSXRecordWriter when the rows or the
+// columns exceed the limits of an Excel worksheet.
+type XLSXLimitError struct {
+	What  string // "rows" or "columns"
+	Limit int
+}
+
+func (e *XLSXLimitError) Error() string {
+	return fmt.Sprintf("Too many %s for XLSX (limit %d)", e.What, e.Limit)
+}
+
+// OptionError is returned by NewConverter when an option is invalid.
+type OptionError struct {
+	Option string // name of the option, e.g. "Writer.Dialect"
+	Reason string
+}
+
+func (e *OptionError) Error() string {
+	return fmt.Sprintf("Invalid option %s: %s", e.Option, e.Reason)
+}
+
+// DecodeError is returned when the input of ConvertReader is not valid JSON.
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return "Invalid JSON: " + e.Err.Error()
+}
+
+// Unwrap returns the error of the decoder.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
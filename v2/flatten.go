@@ -0,0 +1,439 @@
+package json2csv
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yukithm/json2csv/v2/jsonpointer"
+)
+
+var jsonNumberType = reflect.TypeOf(json.Number(""))
+
+type mapKeys []reflect.Value
+
+func (k mapKeys) Len() int           { return len(k) }
+func (k mapKeys) Swap(i, j int)      { k[i], k[j] = k[j], k[i] }
+func (k mapKeys) Less(i, j int) bool { return k[i].String() < k[j].String() }
+
+func sortedMapKeys(v reflect.Value) []reflect.Value {
+	var keys mapKeys = v.MapKeys()
+	sort.Sort(keys)
+	return keys
+}
+
+// KeyValue represents key(path)/value map.
+type KeyValue map[string]interface{}
+
+// Keys returns all keys.
+func (kv KeyValue) Keys() []string {
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Get returns the value of the key (JSON Pointer).
+func (kv KeyValue) Get(pointer string) (interface{}, bool) {
+	v, ok := kv[pointer]
+	return v, ok
+}
+
+// SortedKeys returns all keys in the column order of the CSV: shallow keys
+// first, then by each token.
+func (kv KeyValue) SortedKeys() []string {
+	pts := make(pointers, 0, len(kv))
+	for k := range kv {
+		pointer, err := jsonpointer.New(k)
+		if err != nil {
+			// not a JSON Pointer
+			keys := kv.Keys()
+			sort.Strings(keys)
+			return keys
+		}
+		pts = append(pts, pointer)
+	}
+	sort.Sort(pts)
+	return pts.Strings()
+}
+
+// Subset returns a new KeyValue which has the values at the prefix (JSON
+// Pointer) and under it, e.g. "/user" and "/user/name" but not "/username"
+// for "/user". The keys are kept as they are.
+func (kv KeyValue) Subset(prefix string) KeyValue {
+	sub := make(KeyValue)
+	for k, v := range kv {
+		if k == prefix || strings.HasPrefix(k, prefix+"/") {
+			sub[k] = v
+		}
+	}
+	return sub
+}
+
+// Merge returns a new KeyValue which has the values of kv and other.
+// The values of other take precedence.
+func (kv KeyValue) Merge(other KeyValue) KeyValue {
+	merged := make(KeyValue, len(kv)+len(other))
+	for k, v := range kv {
+		merged[k] = v
+	}
+	for k, v := range other {
+		merged[k] = v
+	}
+	return merged
+}
+
+// Approximate overhead in bytes of a map entry, a string header and an interface.
+const (
+	mapEntrySize  = 48
+	stringSize    = 16
+	interfaceSize = 16
+)
+
+// size returns the approximate size of the KeyValue in memory.
+func (kv KeyValue) size() int64 {
+	n := int64(mapEntrySize)
+	for k, v := range kv {
+		n += mapEntrySize + stringSize + int64(len(k)) + interfaceSize
+		switch v := v.(type) {
+		case string:
+			n += stringSize + int64(len(v))
+		case json.Number:
+			n += stringSize + int64(len(v))
+		default:
+			n += 8
+		}
+	}
+	return n
+}
+
+// FlattenOptions represents options of flattening.
+type FlattenOptions struct {
+	// MaxDepth is the maximum number of tokens of the keys. Objects and
+	// arrays nested deeper are JSON text at the key of the depth, e.g.
+	// {"a":{"b":{"c":1}}} is "/a/b": {"c":1} with MaxDepth 2.
+	// Zero means no limit.
+	MaxDepth int
+
+	// Arrays specifies how arrays are flattened.
+	Arrays ArrayStyle
+
+	// ArraySeparator is the separator of JoinArrays ("," if empty).
+	ArraySeparator string
+
+	// KeepNested keeps the objects and arrays as JSON text at their keys in
+	// addition to the flattened values, e.g. "/a": {"b":1} and "/a/b": 1.
+	KeepNested bool
+
+	// Unsupported decides how values of types which have no CSV
+	// representation (channels, functions, structs, pointers, ...) are
+	// converted. null is always omitted.
+	Unsupported UnsupportedPolicy
+
+	// Fallback converts the value of an unsupported type at the pointer
+	// with FallbackUnsupported. It must return a value of a supported type.
+	Fallback func(pointer string, value interface{}) (interface{}, error)
+
+	// Binary decides how []byte values (and base64 strings detected by
+	// Base64MinLength) are converted.
+	Binary BinaryPolicy
+
+	// Base64MinLength detects strings of at least the length which are valid
+	// standard base64 (and not hex only) as binary. Zero disables the detection.
+	// The detected strings are kept as is with ArrayBinary and Base64Binary.
+	Base64MinLength int
+
+	// Base64Keys limits the detection by Base64MinLength to the keys (JSON
+	// Pointers), so that e.g. long IDs which happen to be valid base64 are
+	// kept. Empty means all strings.
+	Base64Keys []string
+}
+
+// ArrayStyle represents how arrays are flattened.
+type ArrayStyle uint
+
+// Array style
+const (
+	// Each element has the key with the index, e.g. "/tags/0" and "/tags/1".
+	IndexArrays ArrayStyle = iota
+
+	// The array is JSON text, e.g. "/tags": ["a","b"].
+	JSONArrays
+
+	// The elements are joined by ArraySeparator, e.g. "/tags": "a,b".
+	// Arrays which have objects or arrays are JSON text.
+	JoinArrays
+)
+
+// BinaryPolicy represents how binary values are converted.
+type BinaryPolicy uint
+
+// Binary policy
+const (
+	// []byte is flattened as an array of numbers.
+	ArrayBinary BinaryPolicy = iota
+
+	// Base64 encoded string, same as encoding/json.
+	Base64Binary
+
+	// Hex encoded string.
+	HexBinary
+
+	// Placeholder with the length like "[binary 1024 bytes]".
+	LengthBinary
+
+	// The column is omitted.
+	SkipBinary
+)
+
+// UnsupportedPolicy represents how values of unsupported types are converted.
+type UnsupportedPolicy uint
+
+// Unsupported value policy
+const (
+	// The column is omitted.
+	SkipUnsupported UnsupportedPolicy = iota
+
+	// The cell is empty.
+	EmptyUnsupported
+
+	// The conversion fails with *UnsupportedValueError.
+	ErrorUnsupported
+
+	// Options.Fallback converts the value.
+	FallbackUnsupported
+)
+
+// UnsupportedValueError is returned when a value of an unsupported type is
+// found with ErrorUnsupported.
+type UnsupportedValueError struct {
+	Pointer string       // JSON Pointer of the value
+	Type    reflect.Type // type of the value
+}
+
+func (e *UnsupportedValueError) Error() string {
+	return fmt.Sprintf("Unsupported value of type %s at %q", e.Type, e.Pointer)
+}
+
+// Flatten flattens the object into a row, or the array of objects into the
+// rows, without the CSV layer. The results are the same as Converter.Flatten
+// with the options.
+func Flatten(v interface{}, opts FlattenOptions) ([]KeyValue, error) {
+	return appendRows(context.Background(), []KeyValue{}, v, &Options{Flatten: opts})
+}
+
+func flatten(obj interface{}, opts *FlattenOptions) (KeyValue, error) {
+	f := make(KeyValue, 0)
+	key := jsonpointer.JSONPointer{}
+	if err := _flatten(f, obj, key, opts); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func _flatten(out KeyValue, obj interface{}, key jsonpointer.JSONPointer, opts *FlattenOptions) error {
+	value, ok := obj.(reflect.Value)
+	if !ok {
+		value = reflect.ValueOf(obj)
+	}
+	for value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+
+	if !value.IsValid() {
+		// null is omitted
+		return nil
+	}
+	if value.Type().AssignableTo(jsonNumberType) {
+		out[key.String()] = value.Interface().(json.Number)
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.Map:
+		if nested, err := opts.flattenNested(out, value, key); nested || err != nil {
+			return err
+		}
+		return _flattenMap(out, value, key, opts)
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.Uint8 && opts.Binary != ArrayBinary {
+			setBinary(out, key, value.Bytes(), opts.Binary)
+			return nil
+		}
+		if key.Len() > 0 && opts.Arrays == JoinArrays {
+			if joined, ok := joinArray(value, opts.ArraySeparator); ok {
+				out[key.String()] = joined
+				return nil
+			}
+			return setJSON(out, key, value)
+		}
+		if key.Len() > 0 && opts.Arrays == JSONArrays {
+			return setJSON(out, key, value)
+		}
+		if nested, err := opts.flattenNested(out, value, key); nested || err != nil {
+			return err
+		}
+		return _flattenSlice(out, value, key, opts)
+	case reflect.String:
+		if opts.Binary > Base64Binary && opts.detectsBase64(key.String()) {
+			if b, ok := decodeBase64(value.String(), opts.Base64MinLength); ok {
+				setBinary(out, key, b, opts.Binary)
+				return nil
+			}
+		}
+		out[key.String()] = value.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		out[key.String()] = value.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		out[key.String()] = value.Uint()
+	case reflect.Float32, reflect.Float64:
+		out[key.String()] = value.Float()
+	case reflect.Bool:
+		out[key.String()] = value.Bool()
+	default:
+		return flattenUnsupported(out, value, key, opts)
+	}
+	return nil
+}
+
+// flattenNested sets the object or the array at the key as JSON text by
+// MaxDepth and KeepNested. It reports whether the value must not be
+// flattened further.
+func (opts *FlattenOptions) flattenNested(out KeyValue, value reflect.Value, key jsonpointer.JSONPointer) (bool, error) {
+	if key.Len() == 0 {
+		return false, nil
+	}
+	if opts.MaxDepth > 0 && key.Len() >= opts.MaxDepth {
+		return true, setJSON(out, key, value)
+	}
+	if opts.KeepNested {
+		return false, setJSON(out, key, value)
+	}
+	return false, nil
+}
+
+// setJSON sets the value as JSON text.
+func setJSON(out KeyValue, key jsonpointer.JSONPointer, value reflect.Value) error {
+	b, err := json.Marshal(value.Interface())
+	if err != nil {
+		return err
+	}
+	out[key.String()] = string(b)
+	return nil
+}
+
+// joinArray joins the elements of the array by the separator ("," if empty).
+// It fails if the array has objects or arrays. null elements are empty.
+func joinArray(value reflect.Value, sep string) (string, bool) {
+	if sep == "" {
+		sep = ","
+	}
+	elems := make([]string, value.Len())
+	for i := range elems {
+		elem := valueOf(value.Index(i))
+		switch elem.Kind() {
+		case reflect.Invalid, reflect.Interface:
+			// null
+		case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct, reflect.Ptr,
+			reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+			return "", false
+		default:
+			elems[i] = toString(elem.Interface())
+		}
+	}
+	return strings.Join(elems, sep), true
+}
+
+// setBinary sets the binary value converted by the policy.
+func setBinary(out KeyValue, key jsonpointer.JSONPointer, b []byte, policy BinaryPolicy) {
+	switch policy {
+	case Base64Binary:
+		out[key.String()] = base64.StdEncoding.EncodeToString(b)
+	case HexBinary:
+		out[key.String()] = hex.EncodeToString(b)
+	case LengthBinary:
+		out[key.String()] = fmt.Sprintf("[binary %d bytes]", len(b))
+	}
+}
+
+// detectsBase64 reports whether the string at the key is checked for base64
+// by Base64Keys.
+func (opts *FlattenOptions) detectsBase64(key string) bool {
+	if len(opts.Base64Keys) == 0 {
+		return true
+	}
+	for _, k := range opts.Base64Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeBase64 decodes s if it is valid standard base64 of at least
+// minLength characters. Zero minLength disables the detection.
+// Hex strings (e.g. digests) are not base64 though they are valid.
+func decodeBase64(s string, minLength int) ([]byte, bool) {
+	if minLength <= 0 || len(s) < minLength || len(s)%4 != 0 || isHex(s) {
+		return nil, false
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+// flattenUnsupported converts the value of an unsupported type by
+// opts.Unsupported.
+func flattenUnsupported(out KeyValue, value reflect.Value, key jsonpointer.JSONPointer, opts *FlattenOptions) error {
+	switch opts.Unsupported {
+	case EmptyUnsupported:
+		out[key.String()] = ""
+	case ErrorUnsupported:
+		return &UnsupportedValueError{Pointer: key.String(), Type: value.Type()}
+	case FallbackUnsupported:
+		if opts.Fallback == nil {
+			return &UnsupportedValueError{Pointer: key.String(), Type: value.Type()}
+		}
+		v, err := opts.Fallback(key.String(), value.Interface())
+		if err != nil {
+			return err
+		}
+		// The fallback must return a supported value.
+		strict := *opts
+		strict.Unsupported = ErrorUnsupported
+		return _flatten(out, v, key, &strict)
+	}
+	return nil
+}
+
+func _flattenMap(out map[string]interface{}, value reflect.Value, prefix jsonpointer.JSONPointer, opts *FlattenOptions) error {
+	keys := sortedMapKeys(value)
+	for _, key := range keys {
+		pointer := prefix.Clone()
+		pointer.AppendString(key.String())
+		if err := _flatten(out, value.MapIndex(key).Interface(), pointer, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func _flattenSlice(out map[string]interface{}, value reflect.Value, prefix jsonpointer.JSONPointer, opts *FlattenOptions) error {
+	for i := 0; i < value.Len(); i++ {
+		pointer := prefix.Clone()
+		pointer.AppendString(strconv.Itoa(i))
+		if err := _flatten(out, value.Index(i).Interface(), pointer, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
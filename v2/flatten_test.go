@@ -0,0 +1,97 @@
+package json2csv
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// Decode JSON with UseNumber option.
+func json2obj(jsonstr string) (interface{}, error) {
+	r := bytes.NewReader([]byte(jsonstr))
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	var obj interface{}
+	if err := d.Decode(&obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func TestFlatten(t *testing.T) {
+	obj, err := json2obj(`{"id": 1, "user": {"name": "foo", "address": {"city": "Tokyo"}}, "tags": ["a", null, 2], "items": [{"x": 1}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		opts     FlattenOptions
+		expected KeyValue
+	}{
+		{
+			FlattenOptions{},
+			KeyValue{"/id": json.Number("1"), "/user/name": "foo", "/user/address/city": "Tokyo", "/tags/0": "a", "/tags/2": json.Number("2"), "/items/0/x": json.Number("1")},
+		},
+		{
+			FlattenOptions{MaxDepth: 1},
+			KeyValue{"/id": json.Number("1"), "/user": `{"address":{"city":"Tokyo"},"name":"foo"}`, "/tags": `["a",null,2]`, "/items": `[{"x":1}]`},
+		},
+		{
+			FlattenOptions{MaxDepth: 2},
+			KeyValue{"/id": json.Number("1"), "/user/name": "foo", "/user/address": `{"city":"Tokyo"}`, "/tags/0": "a", "/tags/2": json.Number("2"), "/items/0": `{"x":1}`},
+		},
+		{
+			FlattenOptions{Arrays: JSONArrays},
+			KeyValue{"/id": json.Number("1"), "/user/name": "foo", "/user/address/city": "Tokyo", "/tags": `["a",null,2]`, "/items": `[{"x":1}]`},
+		},
+		{
+			FlattenOptions{Arrays: JoinArrays, ArraySeparator: "|"},
+			KeyValue{"/id": json.Number("1"), "/user/name": "foo", "/user/address/city": "Tokyo", "/tags": "a||2", "/items": `[{"x":1}]`},
+		},
+		{
+			FlattenOptions{KeepNested: true, MaxDepth: 2},
+			KeyValue{"/id": json.Number("1"), "/user": `{"address":{"city":"Tokyo"},"name":"foo"}`, "/user/name": "foo", "/user/address": `{"city":"Tokyo"}`,
+				"/tags": `["a",null,2]`, "/tags/0": "a", "/tags/2": json.Number("2"), "/items": `[{"x":1}]`, "/items/0": `{"x":1}`},
+		},
+	}
+
+	for caseIndex, testCase := range testCases {
+		actual, err := Flatten(obj, testCase.opts)
+		if err != nil {
+			t.Errorf("%d: Unexpected error %v", caseIndex, err)
+		} else if !reflect.DeepEqual([]KeyValue{testCase.expected}, actual) {
+			t.Errorf("%d: Expected %#v, but %#v", caseIndex, testCase.expected, actual)
+		}
+	}
+}
+
+func TestKeyValue(t *testing.T) {
+	kv := KeyValue{"/user/name": "foo", "/user/tags/10": "b", "/user/tags/2": "a", "/username": "bar", "/id": 1}
+
+	if v, ok := kv.Get("/user/name"); !ok || v != "foo" {
+		t.Errorf("Expected %q, but %v, %v", "foo", v, ok)
+	}
+	if v, ok := kv.Get("/user"); ok {
+		t.Errorf("Expected no value, but %v", v)
+	}
+
+	expectedKeys := []string{"/id", "/username", "/user/name", "/user/tags/10", "/user/tags/2"}
+	if keys := kv.SortedKeys(); !reflect.DeepEqual(expectedKeys, keys) {
+		t.Errorf("Expected %q, but %q", expectedKeys, keys)
+	}
+
+	expectedSubset := KeyValue{"/user/tags/10": "b", "/user/tags/2": "a"}
+	if sub := kv.Subset("/user/tags"); !reflect.DeepEqual(expectedSubset, sub) {
+		t.Errorf("Expected %#v, but %#v", expectedSubset, sub)
+	}
+	if sub := kv.Subset("/user"); len(sub) != 3 {
+		t.Errorf("Expected 3 values, but %#v", sub)
+	}
+
+	merged := KeyValue{"/id": 1, "/name": "foo"}.Merge(KeyValue{"/name": "bar", "/age": 2})
+	expectedMerged := KeyValue{"/id": 1, "/name": "bar", "/age": 2}
+	if !reflect.DeepEqual(expectedMerged, merged) {
+		t.Errorf("Expected %#v, but %#v", expectedMerged, merged)
+	}
+}
@@ -0,0 +1,207 @@
+package json2csv
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/yukithm/json2csv/v2/jsonpointer"
+)
+
+// generateEpoch is the base of generated dates.
+var generateEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// schemaNode is a node of the tree built from the keys of a schema.
+type schemaNode struct {
+	key      string // JSON Pointer of the node
+	children map[jsonpointer.Token]*schemaNode
+	order    []jsonpointer.Token
+}
+
+func (n *schemaNode) isLeaf() bool {
+	return len(n.children) == 0
+}
+
+// isArray reports whether all the children are array indexes.
+func (n *schemaNode) isArray() bool {
+	for _, token := range n.order {
+		if !token.IsIndex() {
+			return false
+		}
+	}
+	return true
+}
+
+// GenerateJSON returns n synthetic objects which have values at all the keys
+// (JSON Pointers) of the schema, so that the pipelines consuming the CSV can
+// be tested against realistic shapes without production data. Flattening
+// them yields the same keys.
+//
+// Objects whose keys are all array indexes are arrays. The values are
+// guessed from the names: "id" is the row number, names like "created_at"
+// or "date" are dates, "email" is an address, names like "is_active" are
+// booleans, names like "price" or "count" are numbers, and others are
+// strings. The output is the same for the same arguments.
+func GenerateJSON(schema []string, n int) ([]interface{}, error) {
+	root := &schemaNode{}
+	for _, key := range schema {
+		if err := root.add(key); err != nil {
+			return nil, err
+		}
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	objs := make([]interface{}, n)
+	for i := range objs {
+		objs[i] = root.generate(i+1, "", rnd)
+	}
+	return objs, nil
+}
+
+// add adds the key to the tree.
+func (n *schemaNode) add(key string) error {
+	pointer, err := jsonpointer.New(key)
+	if err != nil {
+		return err
+	}
+	if pointer.Len() == 0 {
+		return fmt.Errorf("Invalid schema key %q: the root can't be a value", key)
+	}
+
+	node := n
+	existing := true
+	for i, token := range pointer {
+		if existing && node.isLeaf() && node.key != "" {
+			return fmt.Errorf("Conflicting schema keys %q and %q", node.key, key)
+		}
+		child, ok := node.children[token]
+		if !ok {
+			existing = false
+			if node.children == nil {
+				node.children = make(map[jsonpointer.Token]*schemaNode)
+			}
+			child = &schemaNode{key: pointer[:i+1].String()}
+			node.children[token] = child
+			node.order = append(node.order, token)
+		}
+		node = child
+	}
+	if !node.isLeaf() {
+		return fmt.Errorf("Conflicting schema keys %q and %q", key, node.firstLeaf())
+	}
+	return nil
+}
+
+// firstLeaf returns the key of the first leaf under the node.
+func (n *schemaNode) firstLeaf() string {
+	if n.isLeaf() {
+		return n.key
+	}
+	return n.children[n.order[0]].firstLeaf()
+}
+
+// generate returns the value of the node at the row.
+// The name is the last non-index token, which is used to guess the value.
+func (n *schemaNode) generate(row int, name string, rnd *rand.Rand) interface{} {
+	if n.isLeaf() {
+		return generateValue(row, name, rnd)
+	}
+
+	if n.isArray() {
+		size := 0
+		for _, token := range n.order {
+			if i, _ := strconv.Atoi(string(token)); i+1 > size {
+				size = i + 1
+			}
+		}
+		// Missing indexes are null, which Flatten omits.
+		arr := make([]interface{}, size)
+		for _, token := range n.order {
+			i, _ := strconv.Atoi(string(token))
+			arr[i] = n.children[token].generate(row, name, rnd)
+		}
+		return arr
+	}
+
+	obj := make(map[string]interface{}, len(n.children))
+	for _, token := range n.order {
+		obj[string(token)] = n.children[token].generate(row, string(token), rnd)
+	}
+	return obj
+}
+
+// generateValue returns a value which looks like the name.
+func generateValue(row int, name string, rnd *rand.Rand) interface{} {
+	words := splitWords(name)
+	if len(words) == 0 {
+		return fmt.Sprintf("value %d", row)
+	}
+	first, last := words[0], words[len(words)-1]
+
+	switch {
+	case last == "id":
+		return json.Number(strconv.Itoa(row))
+	case last == "at" || containsWord(words, "time", "timestamp"):
+		t := generateEpoch.Add(time.Duration(rnd.Int63n(int64(365 * 24 * time.Hour)))).Truncate(time.Second)
+		return t.Format(time.RFC3339)
+	case containsWord(words, "date", "birthday"):
+		return generateEpoch.AddDate(0, 0, rnd.Intn(365)).Format("2006-01-02")
+	case containsWord(words, "email", "mail"):
+		return fmt.Sprintf("user%d@example.com", row)
+	case containsWord([]string{first}, "is", "has", "enabled", "active"):
+		return rnd.Intn(2) == 0
+	case containsWord([]string{last}, "count", "quantity", "qty", "age", "size", "num", "number"):
+		return json.Number(strconv.Itoa(rnd.Intn(100)))
+	case containsWord([]string{last}, "price", "amount", "total", "cost", "rate", "score"):
+		return json.Number(strconv.FormatFloat(float64(rnd.Intn(100000))/100, 'f', 2, 64))
+	default:
+		return fmt.Sprintf("%s %d", name, row)
+	}
+}
+
+// splitWords splits the name like "createdAt" or "created_at" into the
+// lower case words.
+func splitWords(name string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	prevLower := false
+	for _, r := range name {
+		switch {
+		case unicode.IsUpper(r):
+			if prevLower {
+				flush()
+			}
+			word = append(word, r)
+			prevLower = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+			prevLower = true
+		default:
+			flush()
+			prevLower = false
+		}
+	}
+	flush()
+	return words
+}
+
+func containsWord(words []string, candidates ...string) bool {
+	for _, word := range words {
+		for _, c := range candidates {
+			if word == c {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package json2csv
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestGenerateJSON(t *testing.T) {
+	schema := []string{"/id", "/user/email", "/user/createdAt", "/tags/0", "/tags/2", "/items/0/price", "/is_active", "/note"}
+	objs, err := GenerateJSON(schema, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 3 {
+		t.Fatalf("Expected 3 objects, but %d", len(objs))
+	}
+
+	results, err := Flatten(objs, FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := NewCSVHeader(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := append([]string{}, schema...)
+	sort.Strings(expected)
+	actual := append([]string{}, header...)
+	sort.Strings(actual)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %q, but %q", expected, actual)
+	}
+
+	row := results[1]
+	for key, want := range map[string]interface{}{
+		"/id":         json.Number("2"),
+		"/user/email": "user2@example.com",
+		"/note":       "note 2",
+		"/tags/0":     "tags 2",
+	} {
+		if row[key] != want {
+			t.Errorf("%s: Expected %#v, but %#v", key, want, row[key])
+		}
+	}
+	if _, ok := row["/is_active"].(bool); !ok {
+		t.Errorf("Expected bool, but %#v", row["/is_active"])
+	}
+
+	again, _ := GenerateJSON(schema, 3)
+	if !reflect.DeepEqual(objs, again) {
+		t.Errorf("Expected the same output for the same arguments")
+	}
+}
+
+func TestGenerateJSONInvalidSchema(t *testing.T) {
+	testCases := []struct {
+		schema []string
+		err    string
+	}{
+		{[]string{"/a", "/a/b"}, `Conflicting schema keys "/a" and "/a/b"`},
+		{[]string{"/a/b", "/a"}, `Conflicting schema keys "/a" and "/a/b"`},
+		{[]string{""}, `Invalid schema key "": the root can't be a value`},
+	}
+
+	for caseIndex, testCase := range testCases {
+		_, err := GenerateJSON(testCase.schema, 1)
+		if err == nil || err.Error() != testCase.err {
+			t.Errorf("%d: Expected %q, but %v", caseIndex, testCase.err, err)
+		}
+	}
+}
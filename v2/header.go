@@ -0,0 +1,170 @@
+package json2csv
+
+import (
+	"sort"
+
+	"github.com/yukithm/json2csv/v2/jsonpointer"
+)
+
+// CSVHeader is the columns of CSV as keys (JSON Pointers).
+type CSVHeader []string
+
+// NewCSVHeader returns the sorted keys of the results, which are the columns
+// written by CSVWriter.
+func NewCSVHeader(results []KeyValue) (CSVHeader, error) {
+	pts, err := allPointers(results, nil)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(pts)
+	return CSVHeader(pts.Strings()), nil
+}
+
+// NewCSVHeaderFromStrings returns the header of the keys (JSON Pointers) in
+// the given order. Duplicate keys are removed.
+func NewCSVHeaderFromStrings(keys []string) (CSVHeader, error) {
+	header := make(CSVHeader, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if _, err := jsonpointer.New(key); err != nil {
+			return nil, err
+		}
+		if !seen[key] {
+			seen[key] = true
+			header = append(header, key)
+		}
+	}
+	return header, nil
+}
+
+// Contains reports whether the header has the key.
+func (h CSVHeader) Contains(key string) bool {
+	for _, k := range h {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Union returns a new header which has the keys of h followed by the keys
+// of other which are not in h.
+func (h CSVHeader) Union(other CSVHeader) CSVHeader {
+	union := make(CSVHeader, 0, len(h)+len(other))
+	seen := make(map[string]bool, len(h)+len(other))
+	for _, headers := range []CSVHeader{h, other} {
+		for _, key := range headers {
+			if !seen[key] {
+				seen[key] = true
+				union = append(union, key)
+			}
+		}
+	}
+	return union
+}
+
+// Intersect returns a new header which has the keys of h which are also in
+// other, in the order of h.
+func (h CSVHeader) Intersect(other CSVHeader) CSVHeader {
+	inOther := make(map[string]bool, len(other))
+	for _, key := range other {
+		inOther[key] = true
+	}
+	intersection := CSVHeader{}
+	for _, key := range h {
+		if inOther[key] {
+			intersection = append(intersection, key)
+			// no duplicates
+			delete(inOther, key)
+		}
+	}
+	return intersection
+}
+
+// Collision is a header name shared by several keys in a header style,
+// e.g. "/a.b" and "/a/b" are both "a.b" in DotNotationStyle.
+type Collision struct {
+	Style KeyStyle
+	Name  string
+	Keys  []string
+}
+
+// HeaderDiff is the differences between two headers.
+type HeaderDiff struct {
+	Added      []string    // keys only in the new header
+	Removed    []string    // keys only in the old header
+	Collisions []Collision // header names shared by several keys of the both headers
+}
+
+// Equal reports whether both headers have the same keys.
+// Collisions are not considered.
+func (d HeaderDiff) Equal() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// headerStyles is the header styles checked for collisions.
+var headerStyles = []KeyStyle{JSONPointerStyle, SlashStyle, DotNotationStyle, DotBracketStyle}
+
+// CompareHeaders compares the old header a and the new header b.
+// The order of the keys is ignored. Invalid keys are ignored in Collisions.
+func CompareHeaders(a, b CSVHeader) HeaderDiff {
+	inA := make(map[string]bool, len(a))
+	for _, key := range a {
+		inA[key] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, key := range b {
+		inB[key] = true
+	}
+
+	var diff HeaderDiff
+	for _, key := range b {
+		if !inA[key] {
+			diff.Added = append(diff.Added, key)
+		}
+	}
+	for _, key := range a {
+		if !inB[key] {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+
+	var keys []string
+	var pts pointers
+	seen := make(map[string]bool, len(a)+len(b))
+	for _, key := range append(append([]string{}, a...), b...) {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		pointer, err := pointerCache(nil).Parse(key)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+		pts = append(pts, pointer)
+	}
+
+	for _, style := range headerStyles {
+		names := styledHeader(style, pts)
+		byName := make(map[string][]string, len(names))
+		var order []string
+		for i, name := range names {
+			if _, ok := byName[name]; !ok {
+				order = append(order, name)
+			}
+			byName[name] = append(byName[name], keys[i])
+		}
+		for _, name := range order {
+			if len(byName[name]) > 1 {
+				diff.Collisions = append(diff.Collisions, Collision{
+					Style: style,
+					Name:  name,
+					Keys:  byName[name],
+				})
+			}
+		}
+	}
+
+	return diff
+}
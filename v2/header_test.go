@@ -0,0 +1,77 @@
+package json2csv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareHeaders(t *testing.T) {
+	a := CSVHeader{"/id", "/user/zip", "/a.b"}
+	b := CSVHeader{"/id", "/user/address/zip", "/a/b"}
+
+	diff := CompareHeaders(a, b)
+	if want := []string{"/user/address/zip", "/a/b"}; !reflect.DeepEqual(diff.Added, want) {
+		t.Errorf("Expected %v, but %v", want, diff.Added)
+	}
+	if want := []string{"/user/zip", "/a.b"}; !reflect.DeepEqual(diff.Removed, want) {
+		t.Errorf("Expected %v, but %v", want, diff.Removed)
+	}
+	if diff.Equal() {
+		t.Errorf("Expected not equal")
+	}
+
+	want := []Collision{
+		{DotNotationStyle, "a.b", []string{"/a.b", "/a/b"}},
+		{DotBracketStyle, "a.b", []string{"/a.b", "/a/b"}},
+	}
+	if !reflect.DeepEqual(diff.Collisions, want) {
+		t.Errorf("Expected %v, but %v", want, diff.Collisions)
+	}
+}
+
+func TestCompareHeadersEqual(t *testing.T) {
+	diff := CompareHeaders(CSVHeader{"/a", "/b"}, CSVHeader{"/b", "/a"})
+	if !diff.Equal() || len(diff.Collisions) > 0 {
+		t.Errorf("Expected equal, but %v", diff)
+	}
+}
+
+func TestNewCSVHeaderFromStrings(t *testing.T) {
+	header, err := NewCSVHeaderFromStrings([]string{"/id", "/name", "/id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (CSVHeader{"/id", "/name"}); !reflect.DeepEqual(expected, header) {
+		t.Errorf("Expected %q, but %q", expected, header)
+	}
+
+	if _, err := NewCSVHeaderFromStrings([]string{"id"}); err == nil {
+		t.Errorf("Expected an error for an invalid key")
+	}
+}
+
+func TestCSVHeaderSetOperations(t *testing.T) {
+	a := CSVHeader{"/id", "/name", "/email"}
+	b := CSVHeader{"/email", "/age", "/id"}
+
+	if !a.Contains("/name") || a.Contains("/age") {
+		t.Errorf("Unexpected Contains of %q", a)
+	}
+
+	testCases := []struct {
+		actual   CSVHeader
+		expected CSVHeader
+	}{
+		{a.Union(b), CSVHeader{"/id", "/name", "/email", "/age"}},
+		{b.Union(a), CSVHeader{"/email", "/age", "/id", "/name"}},
+		{a.Intersect(b), CSVHeader{"/id", "/email"}},
+		{b.Intersect(a), CSVHeader{"/email", "/id"}},
+		{a.Intersect(CSVHeader{}), CSVHeader{}},
+	}
+
+	for caseIndex, testCase := range testCases {
+		if !reflect.DeepEqual(testCase.expected, testCase.actual) {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.expected, testCase.actual)
+		}
+	}
+}
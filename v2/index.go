@@ -0,0 +1,73 @@
+package json2csv
+
+import "github.com/yukithm/json2csv/v2/jsonpointer"
+
+// maxPointerCacheSize is the maximum number of pointers kept in pointerCache.
+const maxPointerCacheSize = 100000
+
+// pointerCache keeps parsed JSON Pointers across conversions.
+// A nil pointerCache parses every time.
+type pointerCache map[string]jsonpointer.JSONPointer
+
+// Parse returns the parsed JSON Pointer of the key.
+func (c pointerCache) Parse(key string) (jsonpointer.JSONPointer, error) {
+	if pointer, ok := c[key]; ok {
+		return pointer, nil
+	}
+
+	pointer, err := jsonpointer.New(key)
+	if err != nil {
+		return nil, err
+	}
+	if c != nil {
+		if len(c) >= maxPointerCacheSize {
+			// Start over rather than growing without bound.
+			for k := range c {
+				delete(c, k)
+			}
+		}
+		c[key] = pointer
+	}
+	return pointer, nil
+}
+
+// columnIndex maps keys (JSON Pointers) to the column positions.
+//
+// It is built once and shared by all rows, so building a record costs the
+// number of keys in the row rather than the number of columns, which matters
+// for wide and sparse schemas.
+type columnIndex struct {
+	keys     []string
+	position map[string]int
+}
+
+func newColumnIndex(keys []string) *columnIndex {
+	position := make(map[string]int, len(keys))
+	for i, key := range keys {
+		position[key] = i
+	}
+	return &columnIndex{
+		keys:     keys,
+		position: position,
+	}
+}
+
+// Len returns the number of columns.
+func (idx *columnIndex) Len() int {
+	return len(idx.keys)
+}
+
+// formatFunc formats the value of the column.
+type formatFunc func(column int, value interface{}) field
+
+// Record returns the formatted values of the row in the column order.
+// Missing values are empty fields. Keys which are not in the index are ignored.
+func (idx *columnIndex) Record(kv KeyValue, format formatFunc) []field {
+	record := make([]field, len(idx.keys))
+	for key, value := range kv {
+		if i, ok := idx.position[key]; ok {
+			record[i] = format(i, value)
+		}
+	}
+	return record
+}
@@ -0,0 +1,102 @@
+package json2csv
+
+import (
+	"context"
+	"reflect"
+)
+
+// appendRows flattens JSON (an object or an array of objects) and appends the
+// rows to results, applying Rules, MaxMemory and Timeout of the options.
+func appendRows(ctx context.Context, results []KeyValue, data interface{}, opts *Options) ([]KeyValue, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var used int64
+	add := func(result KeyValue) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for _, rule := range opts.Rules {
+			if err := rule.Apply(result); err != nil {
+				return err
+			}
+		}
+		results = append(results, result)
+		if opts.MaxMemory > 0 {
+			used += result.size()
+			if used > opts.MaxMemory {
+				return &MemoryLimitError{Limit: opts.MaxMemory, Used: used, Rows: len(results)}
+			}
+		}
+		return nil
+	}
+
+	v := valueOf(data)
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Len() > 0 {
+			result, err := flatten(v, &opts.Flatten)
+			if err != nil {
+				return nil, err
+			}
+			if err := add(result); err != nil {
+				return nil, err
+			}
+		}
+	case reflect.Slice:
+		if isObjectArray(v) && opts.Parallelism > 1 {
+			if err := flattenParallel(ctx, v, opts, add); err != nil {
+				return nil, err
+			}
+		} else if isObjectArray(v) {
+			for i := 0; i < v.Len(); i++ {
+				result, err := flatten(v.Index(i), &opts.Flatten)
+				if err != nil {
+					return nil, err
+				}
+				if err := add(result); err != nil {
+					return nil, err
+				}
+			}
+		} else if v.Len() > 0 {
+			result, err := flatten(v, &opts.Flatten)
+			if err != nil {
+				return nil, err
+			}
+			if result != nil {
+				if err := add(result); err != nil {
+					return nil, err
+				}
+			}
+		}
+	default:
+		return nil, ErrUnsupportedJSON
+	}
+
+	return results, nil
+}
+
+func isObjectArray(obj interface{}) bool {
+	value := valueOf(obj)
+	if value.Kind() != reflect.Slice {
+		return false
+	}
+
+	len := value.Len()
+	if len == 0 {
+		return false
+	}
+	for i := 0; i < len; i++ {
+		if valueOf(value.Index(i)).Kind() != reflect.Map {
+			return false
+		}
+	}
+
+	return true
+}
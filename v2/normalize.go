@@ -0,0 +1,47 @@
+package json2csv
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// normalizesStrings reports whether string values are normalized.
+func (w *CSVWriter) normalizesStrings() bool {
+	return w.TrimSpace || w.CollapseSpace || w.NormalizeUnicode
+}
+
+// normalizeString applies TrimSpace, CollapseSpace and NormalizeUnicode to
+// the string value.
+func (w *CSVWriter) normalizeString(s string) string {
+	if w.NormalizeUnicode {
+		s = norm.NFC.String(s)
+	}
+	if w.CollapseSpace {
+		s = collapseSpace(s)
+	}
+	if w.TrimSpace {
+		s = strings.TrimSpace(s)
+	}
+	return s
+}
+
+// collapseSpace replaces each run of white space with a single space.
+func collapseSpace(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		b.WriteRune(r)
+		space = false
+	}
+	return b.String()
+}
@@ -0,0 +1,173 @@
+package json2csv
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// NumberFormat formats the numeric values of a column for presentation, e.g.
+// integer cents as "$12.34" or bytes as "1.5 MB". Values which are not
+// numbers are written as they are.
+type NumberFormat struct {
+	// Currency is the symbol put before the amount, e.g. "$" or "EUR ".
+	Currency string
+
+	// MinorUnits scales the values stored in the minor unit of the currency,
+	// e.g. 2 for integer cents (1234 is 12.34).
+	MinorUnits int
+
+	// Unit converts the values in bytes to the unit, which is appended to
+	// the value: "KB", "MB", "GB", "TB" (by 1000), "KiB", "MiB", "GiB",
+	// "TiB" (by 1024) or "auto" (the largest decimal unit not exceeding the
+	// value).
+	Unit string
+
+	// Precision is the number of digits after the decimal point (except for
+	// bytes). Values are rounded half away from zero.
+	Precision int
+}
+
+var byteUnits = []struct {
+	name  string
+	scale int64
+}{
+	{"B", 1},
+	{"KB", 1000},
+	{"MB", 1000 * 1000},
+	{"GB", 1000 * 1000 * 1000},
+	{"TB", 1000 * 1000 * 1000 * 1000},
+	{"KiB", 1 << 10},
+	{"MiB", 1 << 20},
+	{"GiB", 1 << 30},
+	{"TiB", 1 << 40},
+}
+
+// ParseNumberFormat parses the format spec:
+//   - "currency:SYMBOL" formats amounts with 2 decimals, e.g. "currency:$"
+//   - "currency:SYMBOL:N" formats integers of the minor unit with N decimals,
+//     e.g. "currency:$:2" for cents
+//   - "bytes" formats sizes in bytes by the largest decimal unit
+//   - "bytes:UNIT" formats sizes in bytes by UNIT, e.g. "bytes:MB" or "bytes:GiB"
+//
+// An optional ".N" suffix sets the precision of bytes, e.g. "bytes:MB.2"
+// (1 by default).
+func ParseNumberFormat(spec string) (NumberFormat, error) {
+	parts := strings.SplitN(spec, ":", 3)
+	switch parts[0] {
+	case "currency":
+		if len(parts) < 2 {
+			break
+		}
+		f := NumberFormat{Currency: parts[1], Precision: 2}
+		if len(parts) == 3 {
+			n, err := strconv.Atoi(parts[2])
+			if err != nil || n < 0 {
+				break
+			}
+			f.MinorUnits = n
+			f.Precision = n
+		}
+		return f, nil
+	case "bytes":
+		if len(parts) > 2 {
+			break
+		}
+		f := NumberFormat{Unit: "auto", Precision: 1}
+		if len(parts) == 2 {
+			unit := parts[1]
+			if i := strings.LastIndex(unit, "."); i >= 0 {
+				n, err := strconv.Atoi(unit[i+1:])
+				if err != nil || n < 0 {
+					break
+				}
+				f.Precision = n
+				unit = unit[:i]
+			}
+			if _, ok := byteUnitScale(unit); !ok && unit != "auto" {
+				break
+			}
+			f.Unit = unit
+		}
+		return f, nil
+	}
+	return NumberFormat{}, fmt.Errorf("Invalid number format %q", spec)
+}
+
+// Format returns the formatted value, or false if the value isn't a number.
+func (f NumberFormat) Format(value interface{}) (string, bool) {
+	r, ok := toRat(value)
+	if !ok {
+		return "", false
+	}
+
+	if f.MinorUnits > 0 {
+		scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(f.MinorUnits)), nil)
+		r.Quo(r, new(big.Rat).SetInt(scale))
+	}
+
+	unit := f.Unit
+	if unit == "auto" {
+		abs := new(big.Rat).Abs(r)
+		unit = "B"
+		for _, u := range byteUnits[1:5] {
+			if abs.Cmp(new(big.Rat).SetInt64(u.scale)) >= 0 {
+				unit = u.name
+			}
+		}
+	}
+	if scale, ok := byteUnitScale(unit); ok {
+		r.Quo(r, new(big.Rat).SetInt64(scale))
+	}
+
+	precision := f.Precision
+	if unit == "B" {
+		// Bytes are not divided.
+		precision = 0
+	}
+	s := r.FloatString(precision)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+		if strings.Trim(s, "0.") == "" {
+			// Don't write "-0.00".
+			sign = ""
+		}
+	}
+	s = sign + f.Currency + s
+	if unit != "" {
+		s += " " + unit
+	}
+	return s, true
+}
+
+func byteUnitScale(unit string) (int64, bool) {
+	for _, u := range byteUnits {
+		if u.name == unit {
+			return u.scale, true
+		}
+	}
+	return 0, false
+}
+
+// toRat converts the numeric value to big.Rat.
+func toRat(value interface{}) (*big.Rat, bool) {
+	if n, ok := value.(json.Number); ok {
+		return new(big.Rat).SetString(string(n))
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Rat).SetInt64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(v.Uint())), true
+	case reflect.Float32, reflect.Float64:
+		// Use the shortest decimal representation rather than the exact binary value.
+		return new(big.Rat).SetString(strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()))
+	}
+	return nil, false
+}
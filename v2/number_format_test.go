@@ -0,0 +1,48 @@
+package json2csv
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNumberFormat(t *testing.T) {
+	testCases := []struct {
+		spec     string
+		value    interface{}
+		expected string
+	}{
+		{"currency:$", json.Number("12.345"), "$12.35"},
+		{"currency:$", 3, "$3.00"},
+		{"currency:$:2", json.Number("1234"), "$12.34"},
+		{"currency:$:2", json.Number("-5"), "-$0.05"},
+		{"currency:¥:0", json.Number("1500"), "¥1500"},
+		{"currency:EUR :2", 199.0, "EUR 1.99"},
+		{"bytes", json.Number("512"), "512 B"},
+		{"bytes", json.Number("1500000"), "1.5 MB"},
+		{"bytes:MB.2", json.Number("1234567"), "1.23 MB"},
+		{"bytes:KiB.0", uint(2048), "2 KiB"},
+		{"bytes:GiB", int64(1 << 29), "0.5 GiB"},
+		{"currency:$", "n/a", "n/a"},
+		{"bytes", true, "true"},
+	}
+
+	for caseIndex, testCase := range testCases {
+		f, err := ParseNumberFormat(testCase.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, ok := f.Format(testCase.value)
+		if !ok {
+			actual = toString(testCase.value)
+		}
+		if actual != testCase.expected {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.expected, actual)
+		}
+	}
+
+	for _, spec := range []string{"", "currency", "currency:$:x", "bytes:XB", "bytes:MB.x", "percent"} {
+		if _, err := ParseNumberFormat(spec); err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package json2csv
+
+import (
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yukithm/json2csv/v2/jsonpointer"
+)
+
+// Options are the options of a Converter. The zero value converts JSON to
+// CSV with the JSON Pointers as the header.
+type Options struct {
+	// Flatten are the options of flattening JSON into rows.
+	Flatten FlattenOptions
+
+	// Writer are the options of writing the rows.
+	Writer WriterOptions
+
+	// Rules are applied to each row in order after flattening.
+	Rules []*Rule
+
+	// MaxMemory is the approximate maximum size in bytes of the flattened
+	// rows held in memory. Zero means no limit. The decoded JSON is not
+	// counted, so it doesn't bound the memory used while decoding. With
+	// Parallelism, the rows being flattened by each goroutine are counted
+	// as they are flattened.
+	MaxMemory int64
+
+	// Timeout aborts each conversion exceeding the duration with
+	// context.DeadlineExceeded. Zero means no limit.
+	Timeout time.Duration
+
+	// Parallelism is the number of goroutines flattening and writing the
+	// rows in chunks. The order of the rows is kept. Zero or one converts
+	// sequentially.
+	Parallelism int
+
+	// Decoder is the name of the JSON decoder backend of ConvertReader
+	// ("std" if empty).
+	Decoder string
+
+	// Path is the JSON Pointer of the value converted by ConvertReader and
+	// FlattenReader in the input, e.g. "/data/items". The whole input is
+	// converted if it is empty.
+	Path string
+}
+
+// WriterOptions are the options of writing the rows as CSV.
+type WriterOptions struct {
+	// HeaderStyle is the style of the header names.
+	HeaderStyle KeyStyle
+
+	// Dialect is the delimiters and the quoting (CSVDialect if the zero value).
+	Dialect Dialect
+
+	// UseCRLF terminates the records with CRLF unless Dialect.Terminator is set.
+	UseCRLF bool
+
+	// Transpose writes the columns as rows.
+	Transpose bool
+
+	// QuoteEmpty quotes empty string values ("") to distinguish them from
+	// missing or null values.
+	QuoteEmpty bool
+
+	// Comments are written before the header, each line prefixed with
+	// CommentPrefix ("# " if empty).
+	Comments      []string
+	CommentPrefix string
+
+	// GroupHeader writes a two-row header: the top-level key and the rest of
+	// the path.
+	GroupHeader bool
+
+	// Translator translates the header names.
+	Translator Translator
+
+	// HeaderPrefix and HeaderSuffix are added to every header name.
+	HeaderPrefix string
+	HeaderSuffix string
+
+	// MaxHeaderLength is the maximum length of header names in bytes, shortened
+	// by HeaderTruncation. Zero means no limit.
+	MaxHeaderLength  int
+	HeaderTruncation TruncationStyle
+
+	// PreviousHeader keeps the column order of the previous output.
+	PreviousHeader []string
+
+	// ColumnsFirst is the header names or keys of the columns moved to the
+	// front.
+	ColumnsFirst []string
+
+	// Columns is the keys (JSON Pointers) of the columns in order. If it is
+	// empty, the keys of the rows (of the first record for NewStreamWriter
+	// and NewTransposeWriter) are used.
+	Columns []string
+
+	// NumericStrings protects numeric-looking strings from spreadsheets.
+	NumericStrings ProtectionStyle
+
+	// ColumnQuoting overrides Dialect.Quoting by header name or key.
+	ColumnQuoting map[string]QuoteStyle
+
+	// TrimSpace, CollapseSpace and NormalizeUnicode normalize string values.
+	TrimSpace        bool
+	CollapseSpace    bool
+	NormalizeUnicode bool
+
+	// ColumnTransforms rewrites string values by header name or key.
+	ColumnTransforms map[string][]Transform
+
+	// ColumnFormats formats numeric values by header name or key.
+	ColumnFormats map[string]NumberFormat
+
+	// MaxCellSize is the maximum size of cell values in bytes. Larger values
+	// are written to Overflow, or truncated if Overflow is nil. Zero means no
+	// limit.
+	MaxCellSize int
+	Overflow    io.Writer
+
+	// SelfCheck verifies the output strictly by RFC 4180 before writing it.
+	SelfCheck bool
+}
+
+// validate returns *OptionError if an option is invalid.
+func (o *Options) validate() error {
+	if o.MaxMemory < 0 {
+		return &OptionError{"MaxMemory", "negative"}
+	}
+	if o.Timeout < 0 {
+		return &OptionError{"Timeout", "negative"}
+	}
+	if o.Parallelism < 0 {
+		return &OptionError{"Parallelism", "negative"}
+	}
+	if o.Decoder != "" {
+		if _, ok := LookupDecoder(o.Decoder); !ok {
+			return &OptionError{"Decoder", "unknown decoder " + o.Decoder}
+		}
+	}
+	if o.Path != "" {
+		if _, err := jsonpointer.New(o.Path); err != nil {
+			return &OptionError{"Path", err.Error()}
+		}
+	}
+	if o.Flatten.MaxDepth < 0 {
+		return &OptionError{"Flatten.MaxDepth", "negative"}
+	}
+	if o.Writer.MaxHeaderLength < 0 {
+		return &OptionError{"Writer.MaxHeaderLength", "negative"}
+	}
+	if o.Writer.MaxCellSize < 0 {
+		return &OptionError{"Writer.MaxCellSize", "negative"}
+	}
+	if d := o.Writer.Dialect; d != (Dialect{}) {
+		if d.Comma == 0 || d.Comma == '"' || d.Comma == '\r' || d.Comma == '\n' || !utf8.ValidRune(d.Comma) || d.Comma == utf8.RuneError {
+			return &OptionError{"Writer.Dialect", "invalid delimiter"}
+		}
+		if strings.ContainsRune(d.Terminator, d.Comma) || strings.Contains(d.Terminator, `"`) {
+			return &OptionError{"Writer.Dialect", "invalid terminator"}
+		}
+	}
+	return nil
+}
+
+// configure applies the writer options to the CSVWriter.
+func (o *Options) configure(w *CSVWriter) {
+	wo := &o.Writer
+	if wo.Dialect == (Dialect{}) {
+		w.SetDialect(CSVDialect)
+	} else {
+		w.SetDialect(wo.Dialect)
+	}
+	w.UseCRLF = wo.UseCRLF
+	w.HeaderStyle = wo.HeaderStyle
+	w.Transpose = wo.Transpose
+	w.QuoteEmpty = wo.QuoteEmpty
+	w.Comments = wo.Comments
+	w.CommentPrefix = wo.CommentPrefix
+	w.GroupHeader = wo.GroupHeader
+	w.Translator = wo.Translator
+	w.HeaderPrefix = wo.HeaderPrefix
+	w.HeaderSuffix = wo.HeaderSuffix
+	w.MaxHeaderLength = wo.MaxHeaderLength
+	w.HeaderTruncation = wo.HeaderTruncation
+	w.PreviousHeader = wo.PreviousHeader
+	w.ColumnsFirst = wo.ColumnsFirst
+	w.Columns = wo.Columns
+	w.NumericStrings = wo.NumericStrings
+	w.ColumnQuoting = wo.ColumnQuoting
+	w.TrimSpace = wo.TrimSpace
+	w.CollapseSpace = wo.CollapseSpace
+	w.NormalizeUnicode = wo.NormalizeUnicode
+	w.ColumnTransforms = wo.ColumnTransforms
+	w.ColumnFormats = wo.ColumnFormats
+	w.MaxCellSize = wo.MaxCellSize
+	w.Overflow = wo.Overflow
+	w.SelfCheck = wo.SelfCheck
+	w.Parallelism = o.Parallelism
+}
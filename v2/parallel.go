@@ -0,0 +1,137 @@
+package json2csv
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sync/atomic"
+)
+
+// parallelChunkSize is the number of rows flattened or written by a
+// goroutine at a time.
+const parallelChunkSize = 256
+
+// chunk is the result of a range of rows processed in parallel.
+type chunk struct {
+	start, end int
+	done       chan struct{}
+	err        error
+
+	rows     []KeyValue   // flattened rows
+	out      bytes.Buffer // written CSV
+	overflow bytes.Buffer // written Overflow lines
+}
+
+// runChunks processes [0, n) in chunks by f in up to parallelism goroutines,
+// and passes the processed chunks to merge in order. At most parallelism
+// chunks are held at a time, so the processing doesn't run far ahead of the
+// merge.
+func runChunks(ctx context.Context, n int, parallelism int, f func(c *chunk) error, merge func(c *chunk) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pending := make(chan *chunk, parallelism-1)
+	go func() {
+		defer close(pending)
+		for start := 0; start < n; start += parallelChunkSize {
+			end := start + parallelChunkSize
+			if end > n {
+				end = n
+			}
+			c := &chunk{start: start, end: end, done: make(chan struct{})}
+			select {
+			case pending <- c:
+			case <-ctx.Done():
+				return
+			}
+			go func() {
+				defer close(c.done)
+				if err := ctx.Err(); err != nil {
+					c.err = err
+					return
+				}
+				c.err = f(c)
+			}()
+		}
+	}()
+
+	for c := range pending {
+		<-c.done
+		if c.err != nil {
+			return c.err
+		}
+		if err := merge(c); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// flattenParallel flattens the objects of the array in chunks in parallel,
+// and adds the rows in order.
+//
+// MaxMemory is checked by each chunk as well, counting the rows of the
+// chunks which are not added yet, so that they stop before all of them are
+// flattened.
+func flattenParallel(ctx context.Context, v reflect.Value, opts *Options, add func(KeyValue) error) error {
+	var used, rows int64
+	return runChunks(ctx, v.Len(), opts.Parallelism, func(c *chunk) error {
+		c.rows = make([]KeyValue, 0, c.end-c.start)
+		for i := c.start; i < c.end; i++ {
+			result, err := flatten(v.Index(i), &opts.Flatten)
+			if err != nil {
+				return err
+			}
+			c.rows = append(c.rows, result)
+			if opts.MaxMemory > 0 {
+				n := atomic.AddInt64(&used, result.size())
+				r := atomic.AddInt64(&rows, 1)
+				if n > opts.MaxMemory {
+					return &MemoryLimitError{Limit: opts.MaxMemory, Used: n, Rows: int(r)}
+				}
+			}
+		}
+		return nil
+	}, func(c *chunk) error {
+		for _, result := range c.rows {
+			if err := add(result); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writeParallel writes the rows in chunks formatted and serialized in
+// parallel, and copies the chunks to the output (and Overflow) in order.
+func (w *CSVWriter) writeParallel(keys []string, results []KeyValue, format formatFunc) error {
+	index := newColumnIndex(keys)
+	return runChunks(context.Background(), len(results), w.Parallelism, func(c *chunk) error {
+		cw := *w
+		cw.Reset(&c.out)
+		if w.Overflow != nil {
+			cw.Overflow = &c.overflow
+		}
+		for n := c.start; n < c.end; n++ {
+			record := index.Record(results[n], format)
+			if err := cw.limitCells(n+1, keys, record); err != nil {
+				return err
+			}
+			if err := cw.writeFields(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}, func(c *chunk) error {
+		if _, err := w.out.w.Write(c.out.Bytes()); err != nil {
+			return err
+		}
+		if w.Overflow != nil && c.overflow.Len() > 0 {
+			if _, err := w.Overflow.Write(c.overflow.Bytes()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
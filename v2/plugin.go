@@ -0,0 +1,169 @@
+package json2csv
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Source opens inputs by the location, e.g. "s3://bucket/orders.json".
+//
+// Organizations can add proprietary inputs (internal object stores, message
+// queues) by registering a Source for the URL scheme.
+type Source interface {
+	Open(location string) (io.ReadCloser, error)
+}
+
+// Sink creates outputs by the location, e.g. "s3://bucket/orders.csv".
+//
+// The output is complete when Close returns nil.
+type Sink interface {
+	Create(location string) (io.WriteCloser, error)
+}
+
+// SourceFunc is an adapter to use a function as a Source.
+type SourceFunc func(location string) (io.ReadCloser, error)
+
+// Open calls f(location).
+func (f SourceFunc) Open(location string) (io.ReadCloser, error) {
+	return f(location)
+}
+
+// SinkFunc is an adapter to use a function as a Sink.
+type SinkFunc func(location string) (io.WriteCloser, error)
+
+// Create calls f(location).
+func (f SinkFunc) Create(location string) (io.WriteCloser, error) {
+	return f(location)
+}
+
+// fileSource opens local files. Locations are paths or "file://" URLs.
+var fileSource = SourceFunc(func(location string) (io.ReadCloser, error) {
+	return os.Open(LocalPath(location))
+})
+
+// fileSink creates local files. Locations are paths or "file://" URLs.
+var fileSink = SinkFunc(func(location string) (io.WriteCloser, error) {
+	return os.Create(LocalPath(location))
+})
+
+var (
+	pluginsMu sync.RWMutex
+	sources   = map[string]Source{
+		"file": fileSource,
+	}
+	sinks = map[string]Sink{
+		"file": fileSink,
+	}
+)
+
+// RegisterSource makes a source available for the URL scheme.
+// It replaces the source which has the same scheme.
+func RegisterSource(scheme string, source Source) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	sources[scheme] = source
+}
+
+// RegisterSink makes a sink available for the URL scheme.
+// It replaces the sink which has the same scheme.
+func RegisterSink(scheme string, sink Sink) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	sinks[scheme] = sink
+}
+
+// LookupSource returns the source registered for the URL scheme.
+func LookupSource(scheme string) (Source, bool) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	source, ok := sources[scheme]
+	return source, ok
+}
+
+// LookupSink returns the sink registered for the URL scheme.
+func LookupSink(scheme string) (Sink, bool) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	sink, ok := sinks[scheme]
+	return sink, ok
+}
+
+// SourceSchemes returns the sorted URL schemes of the registered sources.
+func SourceSchemes() []string {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	schemes := make([]string, 0, len(sources))
+	for scheme := range sources {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// SinkSchemes returns the sorted URL schemes of the registered sinks.
+func SinkSchemes() []string {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	schemes := make([]string, 0, len(sinks))
+	for scheme := range sinks {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// OpenSource opens the input by the source registered for the URL scheme of
+// the location. Locations without a scheme are local files.
+func OpenSource(location string) (io.ReadCloser, error) {
+	scheme := locationScheme(location)
+	source, ok := LookupSource(scheme)
+	if !ok {
+		return nil, fmt.Errorf("Unknown source scheme %q", scheme)
+	}
+	return source.Open(location)
+}
+
+// CreateSink creates the output by the sink registered for the URL scheme of
+// the location. Locations without a scheme are local files.
+func CreateSink(location string) (io.WriteCloser, error) {
+	scheme := locationScheme(location)
+	sink, ok := LookupSink(scheme)
+	if !ok {
+		return nil, fmt.Errorf("Unknown sink scheme %q", scheme)
+	}
+	return sink.Create(location)
+}
+
+// IsLocalLocation reports whether the location is a local file.
+func IsLocalLocation(location string) bool {
+	return locationScheme(location) == "file"
+}
+
+// LocalPath returns the file path of the local location, which is a path or
+// a "file://" URL.
+func LocalPath(location string) string {
+	if strings.HasPrefix(strings.ToLower(location), "file://") {
+		return location[len("file://"):]
+	}
+	return location
+}
+
+// locationScheme returns the URL scheme of the location ("file" if none).
+func locationScheme(location string) string {
+	i := strings.Index(location, "://")
+	if i <= 0 {
+		return "file"
+	}
+	scheme := location[:i]
+	for n, r := range scheme {
+		isAlpha := ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+		if !isAlpha && (n == 0 || !(('0' <= r && r <= '9') || r == '+' || r == '-' || r == '.')) {
+			return "file"
+		}
+	}
+	return strings.ToLower(scheme)
+}
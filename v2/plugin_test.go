@@ -0,0 +1,117 @@
+package json2csv
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestLocationScheme(t *testing.T) {
+	testCases := []struct {
+		location string
+		want     string
+	}{
+		{"orders.json", "file"},
+		{"/data/orders.json", "file"},
+		{"file:///data/orders.json", "file"},
+		{"S3://bucket/orders.json", "s3"},
+		{"x-queue+tls://host/topic", "x-queue+tls"},
+		{"C:\\data\\orders.json", "file"},
+		{"dir/a://b", "file"},
+	}
+
+	for caseIndex, testCase := range testCases {
+		if got := locationScheme(testCase.location); got != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, got)
+		}
+	}
+}
+
+func TestRegisterSourceAndSink(t *testing.T) {
+	var b bytes.Buffer
+	RegisterSource("test", SourceFunc(func(location string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(location)), nil
+	}))
+	RegisterSink("test", SinkFunc(func(location string) (io.WriteCloser, error) {
+		return nopWriteCloser{&b}, nil
+	}))
+	defer func() {
+		pluginsMu.Lock()
+		delete(sources, "test")
+		delete(sinks, "test")
+		pluginsMu.Unlock()
+	}()
+
+	r, err := OpenSource("test://foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(r)
+	if string(data) != "test://foo" {
+		t.Errorf("Expected %q, but %q", "test://foo", data)
+	}
+
+	w, err := CreateSink("test://bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(w, "baz")
+	if b.String() != "baz" {
+		t.Errorf("Expected %q, but %q", "baz", b.String())
+	}
+
+	if names := SourceSchemes(); !reflect.DeepEqual(names, []string{"file", "test"}) {
+		t.Errorf("Unexpected schemes %v", names)
+	}
+	if _, err := OpenSource("unknown://foo"); err == nil {
+		t.Error("Expected error for unknown scheme")
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	testCases := []struct {
+		location string
+		want     string
+	}{
+		{"orders.json", "orders.json"},
+		{"/data/orders.json", "/data/orders.json"},
+		{"file:///data/orders.json", "/data/orders.json"},
+		{"FILE://orders.json", "orders.json"},
+	}
+
+	for caseIndex, testCase := range testCases {
+		if got := LocalPath(testCase.location); got != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, got)
+		}
+	}
+}
+
+func TestOpenSourceFileURL(t *testing.T) {
+	f, err := ioutil.TempFile("", "json2csv-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(`{"id": 1}`)
+	f.Close()
+
+	r, err := OpenSource("file://" + f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, _ := ioutil.ReadAll(r)
+	if string(data) != `{"id": 1}` {
+		t.Errorf("Expected %q, but %q", `{"id": 1}`, data)
+	}
+}
@@ -0,0 +1,247 @@
+package json2csv
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+)
+
+// RecordSpool holds records in a temporary file, so that they can be written
+// after the keys of all records are known without holding them in memory.
+//
+// The values keep their types if they are strings, json.Numbers, booleans,
+// integers or floats, as flattened by Flatten. Values of other types (e.g.
+// set by Rules) are held as their string forms, which are written the same
+// but are not transformed or formatted as strings or numbers.
+type RecordSpool struct {
+	// TempDir is the directory of the temporary file (os.TempDir() if empty).
+	TempDir string
+
+	file  *os.File
+	buf   *bufio.Writer
+	keys  map[string]bool
+	count int
+	enc   []byte
+}
+
+// spooledText is a value of an unsupported type held as its string form.
+type spooledText string
+
+// Kinds of the spooled values.
+const (
+	spoolNil byte = iota
+	spoolString
+	spoolNumber
+	spoolBool
+	spoolInt
+	spoolUint
+	spoolFloat
+	spoolFloat32
+	spoolText
+)
+
+// Add appends the record to the spool.
+func (s *RecordSpool) Add(kv KeyValue) error {
+	if s.file == nil {
+		f, err := ioutil.TempFile(s.TempDir, "json2csv-spool-")
+		if err != nil {
+			return err
+		}
+		s.file = f
+		s.buf = bufio.NewWriter(f)
+		s.keys = map[string]bool{}
+	}
+
+	buf := appendUvarint(s.enc[:0], uint64(len(kv)))
+	for key, value := range kv {
+		buf = appendSpoolString(buf, key)
+		buf = appendSpoolValue(buf, value)
+		s.keys[key] = true
+	}
+	s.enc = buf
+	if _, err := s.buf.Write(buf); err != nil {
+		return err
+	}
+	s.count++
+	return nil
+}
+
+// Len returns the number of the records.
+func (s *RecordSpool) Len() int {
+	return s.count
+}
+
+// Keys returns the sorted keys (JSON Pointers) of all records.
+func (s *RecordSpool) Keys() []string {
+	keys := make([]string, 0, len(s.keys))
+	for key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// keySet returns a record which has the keys of all records, to build the
+// header.
+func (s *RecordSpool) keySet() KeyValue {
+	kv := make(KeyValue, len(s.keys))
+	for key := range s.keys {
+		kv[key] = nil
+	}
+	return kv
+}
+
+// Each reads the records back in order and calls fn for each of them.
+func (s *RecordSpool) Each(fn func(kv KeyValue) error) error {
+	if s.file == nil {
+		return nil
+	}
+	if err := s.buf.Flush(); err != nil {
+		return err
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	// Later Adds append to the end.
+	defer s.file.Seek(0, io.SeekEnd)
+
+	r := bufio.NewReader(s.file)
+	for i := 0; i < s.count; i++ {
+		kv, err := readSpoolRecord(r)
+		if err != nil {
+			return err
+		}
+		if err := fn(kv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close removes the temporary file.
+func (s *RecordSpool) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	s.file.Close()
+	err := os.Remove(s.file.Name())
+	s.file, s.buf, s.keys, s.count = nil, nil, nil, 0
+	return err
+}
+
+func appendUvarint(buf []byte, n uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return append(buf, tmp[:binary.PutUvarint(tmp[:], n)]...)
+}
+
+func appendSpoolString(buf []byte, s string) []byte {
+	buf = appendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// appendSpoolValue appends the kind and the encoded value.
+func appendSpoolValue(buf []byte, value interface{}) []byte {
+	if n, ok := value.(json.Number); ok {
+		return appendSpoolString(append(buf, spoolNumber), string(n))
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Invalid:
+		return append(buf, spoolNil)
+	case reflect.String:
+		if _, ok := value.(string); ok {
+			return appendSpoolString(append(buf, spoolString), v.String())
+		}
+	case reflect.Bool:
+		if v.Bool() {
+			return append(buf, spoolBool, 1)
+		}
+		return append(buf, spoolBool, 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendUvarint(append(buf, spoolInt), uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return appendUvarint(append(buf, spoolUint), v.Uint())
+	case reflect.Float32:
+		return appendUvarint(append(buf, spoolFloat32), uint64(math.Float32bits(float32(v.Float()))))
+	case reflect.Float64:
+		return appendUvarint(append(buf, spoolFloat), math.Float64bits(v.Float()))
+	}
+	return appendSpoolString(append(buf, spoolText), toString(value))
+}
+
+func readSpoolString(r *bufio.Reader) (string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", errCorruptSpool
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", errCorruptSpool
+	}
+	return string(b), nil
+}
+
+func readSpoolRecord(r *bufio.Reader) (KeyValue, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, errCorruptSpool
+	}
+	kv := make(KeyValue, n)
+	for i := uint64(0); i < n; i++ {
+		key, err := readSpoolString(r)
+		if err != nil {
+			return nil, err
+		}
+		if kv[key], err = readSpoolValue(r); err != nil {
+			return nil, err
+		}
+	}
+	return kv, nil
+}
+
+func readSpoolValue(r *bufio.Reader) (interface{}, error) {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return nil, errCorruptSpool
+	}
+	switch kind {
+	case spoolNil:
+		return nil, nil
+	case spoolString:
+		return readSpoolString(r)
+	case spoolNumber:
+		s, err := readSpoolString(r)
+		return json.Number(s), err
+	case spoolText:
+		s, err := readSpoolString(r)
+		return spooledText(s), err
+	case spoolBool:
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, errCorruptSpool
+		}
+		return b != 0, nil
+	}
+
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, errCorruptSpool
+	}
+	switch kind {
+	case spoolInt:
+		return int64(n), nil
+	case spoolUint:
+		return n, nil
+	case spoolFloat:
+		return math.Float64frombits(n), nil
+	case spoolFloat32:
+		return math.Float32frombits(uint32(n)), nil
+	}
+	return nil, errCorruptSpool
+}
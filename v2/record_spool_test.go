@@ -0,0 +1,58 @@
+package json2csv_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/yukithm/json2csv/v2"
+)
+
+func TestRecordSpool(t *testing.T) {
+	records := []json2csv.KeyValue{
+		{"/id": json.Number("1"), "/name": "foo"},
+		{"/id": int64(2), "/extra": true},
+		{"/id": 3.5, "/name": "bar"},
+	}
+
+	spool := &json2csv.RecordSpool{}
+	defer spool.Close()
+	for _, record := range records {
+		if err := spool.Add(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := spool.Keys(); len(got) != 3 {
+		t.Errorf("Expected 3 keys, but %v", got)
+	}
+
+	read := func() []string {
+		var got []string
+		err := spool.Each(func(kv json2csv.KeyValue) error {
+			for _, key := range kv.SortedKeys() {
+				got = append(got, fmt.Sprintf("%s=%#v", key, kv[key]))
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	want := []string{`/id="1"`, `/name="foo"`, `/extra=true`, `/id=2`, `/id=3.5`, `/name="bar"`}
+	if got := read(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, but %v", want, got)
+	}
+
+	// The spool can be read again, e.g. after more records.
+	if err := spool.Add(json2csv.KeyValue{"/name": "baz"}); err != nil {
+		t.Fatal(err)
+	}
+	want = append(want, `/name="baz"`)
+	if got := read(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, but %v", want, got)
+	}
+	if spool.Len() != 4 {
+		t.Errorf("Expected 4 records, but %d", spool.Len())
+	}
+}
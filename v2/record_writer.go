@@ -0,0 +1,214 @@
+package json2csv
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"sync"
+)
+
+// RecordWriter writes the header and the records of an output format, e.g.
+// CSV, TSV or XLSX. New formats implement it, and CSVWriter.WriteRecords
+// writes the converted rows through it.
+//
+// Close completes the output (e.g. flushes the buffer or writes the end of
+// the file), but doesn't close the underlying io.Writer.
+type RecordWriter interface {
+	WriteHeader(header []string) error
+	WriteRecord(record []string) error
+	Close() error
+}
+
+// TypedRecordWriter is a RecordWriter which keeps the types of the values,
+// e.g. numbers and booleans in XLSX. CSVWriter.WriteRecords writes the
+// header with the keys and the rows of the values through it, instead of
+// WriteHeader and WriteRecord.
+type TypedRecordWriter interface {
+	RecordWriter
+
+	// WriteKeyedHeader writes the header names of the columns, whose keys
+	// (JSON Pointers) are keys. Previous columns which no longer exist
+	// have empty keys.
+	WriteKeyedHeader(keys []string, header []string) error
+
+	// WriteValues writes a row. Missing values are nil.
+	WriteValues(values []interface{}) error
+}
+
+// DelimitedRecordWriter is a RecordWriter of delimited text, e.g. CSV or
+// TSV. CSVWriter.WriteRecords writes the rows like WriteCSV to its Writer in
+// the dialect of the Writer, so that the comments, the quoting of the fields,
+// Transpose and GroupHeader are applied as well.
+type DelimitedRecordWriter interface {
+	RecordWriter
+
+	// Writer returns the Writer of the records.
+	Writer() *Writer
+}
+
+// FormatFunc returns a RecordWriter which writes the format to w.
+type FormatFunc func(w io.Writer) RecordWriter
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]FormatFunc{
+		"csv": func(w io.Writer) RecordWriter {
+			return NewCSVRecordWriter(w, CSVDialect)
+		},
+		"tsv": func(w io.Writer) RecordWriter {
+			return NewCSVRecordWriter(w, TSVDialect)
+		},
+		"xlsx": func(w io.Writer) RecordWriter {
+			return NewXLSXRecordWriter(w)
+		},
+	}
+)
+
+// RegisterFormat makes an output format available by the name.
+// It replaces the format which has the same name.
+func RegisterFormat(name string, f FormatFunc) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[name] = f
+}
+
+// LookupFormat returns the output format registered by the name.
+func LookupFormat(name string) (FormatFunc, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	f, ok := formats[name]
+	return f, ok
+}
+
+// FormatNames returns the sorted names of the registered output formats.
+func FormatNames() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// csvRecordWriter is a DelimitedRecordWriter for CSV (or TSV, ...).
+type csvRecordWriter struct {
+	w *Writer
+}
+
+// NewCSVRecordWriter returns a DelimitedRecordWriter which writes CSV (or
+// TSV, ...) in the dialect.
+func NewCSVRecordWriter(w io.Writer, d Dialect) RecordWriter {
+	cw := NewWriter(w)
+	cw.SetDialect(d)
+	return &csvRecordWriter{w: cw}
+}
+
+func (w *csvRecordWriter) WriteHeader(header []string) error {
+	return w.w.Write(header)
+}
+
+func (w *csvRecordWriter) WriteRecord(record []string) error {
+	return w.w.Write(record)
+}
+
+func (w *csvRecordWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *csvRecordWriter) Writer() *Writer {
+	return w.w
+}
+
+// WriteRecords writes the header and the rows to the RecordWriter, with the
+// header names and the values of the settings of the writer.
+// DelimitedRecordWriters are written like WriteCSV in their dialect. For
+// other formats, the settings specific to CSV (Transpose, GroupHeader,
+// Comments and quoting) are not applied, and TypedRecordWriters get the
+// values with the string settings applied, but not ColumnFormats. It doesn't
+// close rw.
+func (w *CSVWriter) WriteRecords(rw RecordWriter, results []KeyValue) error {
+	if dw, ok := rw.(DelimitedRecordWriter); ok {
+		return w.writeDelimited(dw.Writer(), results)
+	}
+
+	keys, header, err := w.columns(results)
+	if err != nil {
+		return err
+	}
+	if tw, ok := rw.(TypedRecordWriter); ok {
+		return w.writeValues(tw, keys, header, results)
+	}
+	if err := rw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	index := newColumnIndex(keys)
+	format := w.formatter(keys, header)
+	record := make([]string, len(keys))
+	for i, kv := range results {
+		fields := index.Record(kv, format)
+		if err := w.limitCells(i+1, keys, fields); err != nil {
+			return err
+		}
+		for j, f := range fields {
+			record[j] = f.value
+		}
+		if err := w.contextErr(); err != nil {
+			return err
+		}
+		if err := rw.WriteRecord(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDelimited writes the rows like WriteCSV to out in its dialect.
+func (w *CSVWriter) writeDelimited(out *Writer, results []KeyValue) error {
+	cw := *w
+	cw.out = out
+	cw.Writer = csv.NewWriter(out.w)
+	cw.Comma, cw.UseCRLF = out.Comma, out.UseCRLF
+	cw.Terminator, cw.Quoting = out.Terminator, out.Quoting
+	return cw.WriteCSV(results)
+}
+
+// writeValues writes the header and the rows to the TypedRecordWriter.
+func (w *CSVWriter) writeValues(tw TypedRecordWriter, keys []string, header []string, results []KeyValue) error {
+	if err := tw.WriteKeyedHeader(keys, header); err != nil {
+		return err
+	}
+
+	index := newColumnIndex(keys)
+	transform := w.transformer(keys, header)
+	values := make([]interface{}, len(keys))
+	for n, kv := range results {
+		for i := range values {
+			values[i] = nil
+		}
+		for key, value := range kv {
+			i, ok := index.position[key]
+			if !ok {
+				continue
+			}
+			if str, ok := value.(string); ok {
+				f := field{value: transform(i, str)}
+				if err := w.limitCell(n+1, key, &f); err != nil {
+					return err
+				}
+				value = f.value
+			}
+			values[i] = value
+		}
+		if err := w.contextErr(); err != nil {
+			return err
+		}
+		if err := tw.WriteValues(values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
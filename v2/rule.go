@@ -0,0 +1,311 @@
+package json2csv
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Rule is a conditional assignment evaluated on each row, so that common
+// fix-ups of the data happen in the conversion. See ParseRule for the syntax.
+type Rule struct {
+	text string
+
+	// condition, if any
+	conditional bool
+	subject     string // JSON Pointer
+	op          string // "==", "!=", "empty" or "not empty"
+	literal     string
+
+	target string // JSON Pointer
+
+	// value: a JSON Pointer (optionally negated) or a literal
+	source string
+	negate bool
+	value  interface{}
+	delete bool // null
+}
+
+// ParseRule parses the rule:
+//
+//	[if CONDITION then] TARGET := VALUE
+//
+// CONDITION is one of "KEY == LITERAL", "KEY != LITERAL", "KEY empty" (no
+// value or an empty string) and "KEY not empty". VALUE is a KEY, "-KEY"
+// (the negated number) or a literal. KEYs are JSON Pointers ("/billing/country")
+// or dot-separated names ("billing.country"). LITERALs are JSON strings,
+// numbers, true, false or null (removes TARGET); in CONDITION, bare words are
+// strings as well. Numbers are compared numerically. Assigning a KEY without
+// a value removes TARGET.
+//
+// For example:
+//
+//	if /type == refund then /amount := -/amount
+//	if country empty then country := billing.country
+func ParseRule(s string) (*Rule, error) {
+	tokens, err := splitRule(s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid rule %q: %s", s, err)
+	}
+	r := &Rule{text: s}
+	if err := r.parse(tokens); err != nil {
+		return nil, fmt.Errorf("Invalid rule %q: %s", s, err)
+	}
+	return r, nil
+}
+
+func (r *Rule) String() string {
+	return r.text
+}
+
+func (r *Rule) parse(tokens []string) error {
+	if len(tokens) > 0 && tokens[0] == "if" {
+		then := -1
+		for i, t := range tokens {
+			if t == "then" {
+				then = i
+				break
+			}
+		}
+		if then < 0 {
+			return fmt.Errorf("missing then")
+		}
+		if err := r.parseCondition(tokens[1:then]); err != nil {
+			return err
+		}
+		r.conditional = true
+		tokens = tokens[then+1:]
+	}
+
+	if len(tokens) != 3 || tokens[1] != ":=" {
+		return fmt.Errorf("expected TARGET := VALUE")
+	}
+	var err error
+	if r.target, err = rulePointer(tokens[0]); err != nil {
+		return err
+	}
+	return r.parseValue(tokens[2])
+}
+
+func (r *Rule) parseCondition(tokens []string) error {
+	switch {
+	case len(tokens) == 3 && (tokens[1] == "==" || tokens[1] == "!="):
+		r.op = tokens[1]
+		literal, err := ruleLiteral(tokens[2], true)
+		if err != nil {
+			return err
+		}
+		if literal == nil {
+			return fmt.Errorf("use KEY empty instead of comparing with null")
+		}
+		r.literal = toString(literal)
+	case len(tokens) == 2 && tokens[1] == "empty":
+		r.op = "empty"
+	case len(tokens) == 3 && tokens[1] == "not" && tokens[2] == "empty":
+		r.op = "not empty"
+	default:
+		return fmt.Errorf("expected KEY == LITERAL, KEY != LITERAL, KEY empty or KEY not empty")
+	}
+
+	var err error
+	r.subject, err = rulePointer(tokens[0])
+	return err
+}
+
+func (r *Rule) parseValue(token string) error {
+	if literal, err := ruleLiteral(token, false); err == nil {
+		if literal == nil {
+			r.delete = true
+		}
+		r.value = literal
+		return nil
+	}
+
+	if strings.HasPrefix(token, "-") {
+		r.negate = true
+		token = token[1:]
+	}
+	var err error
+	r.source, err = rulePointer(token)
+	return err
+}
+
+// Apply applies the rule to the row.
+func (r *Rule) Apply(kv KeyValue) error {
+	if r.conditional && !r.matches(kv) {
+		return nil
+	}
+
+	if r.delete {
+		delete(kv, r.target)
+		return nil
+	}
+	if r.source == "" {
+		kv[r.target] = r.value
+		return nil
+	}
+
+	value, ok := kv[r.source]
+	if !ok {
+		delete(kv, r.target)
+		return nil
+	}
+	if r.negate {
+		negated, ok := negateNumber(value)
+		if !ok {
+			return fmt.Errorf("Rule %q: the value of %s is not a number", r.text, r.source)
+		}
+		value = negated
+	}
+	kv[r.target] = value
+	return nil
+}
+
+func (r *Rule) matches(kv KeyValue) bool {
+	value, ok := kv[r.subject]
+	s := ""
+	if ok {
+		s = toString(value)
+	}
+
+	switch r.op {
+	case "empty":
+		return s == ""
+	case "not empty":
+		return s != ""
+	case "==":
+		return ok && equalLiteral(value, s, r.literal)
+	default: // "!="
+		return !ok || !equalLiteral(value, s, r.literal)
+	}
+}
+
+// equalLiteral reports whether the value equals the literal, comparing
+// numbers numerically.
+func equalLiteral(value interface{}, s string, literal string) bool {
+	if s == literal {
+		return true
+	}
+	a, ok := toRat(value)
+	if !ok {
+		a, ok = new(big.Rat).SetString(s)
+	}
+	if !ok {
+		return false
+	}
+	b, ok := new(big.Rat).SetString(literal)
+	return ok && a.Cmp(b) == 0
+}
+
+// negateNumber negates the number, or the string of a number.
+func negateNumber(value interface{}) (interface{}, bool) {
+	var s string
+	switch v := value.(type) {
+	case json.Number:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		r, ok := toRat(value)
+		if !ok {
+			return nil, false
+		}
+		f, _ := r.Neg(r).Float64()
+		return f, true
+	}
+
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return nil, false
+	}
+	// Keep the representation of the number.
+	if strings.HasPrefix(s, "-") {
+		s = s[1:]
+	} else if strings.Trim(s, "0.") != "" {
+		s = "-" + s
+	}
+	if _, ok := value.(json.Number); ok {
+		return json.Number(s), true
+	}
+	return s, true
+}
+
+// splitRule splits the rule into tokens by white space. JSON strings are
+// single tokens.
+func splitRule(s string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		if unicode.IsSpace(rune(s[i])) {
+			i++
+			continue
+		}
+		start := i
+		if s[i] == '"' {
+			// Find the closing quote.
+			for i++; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			i++
+		} else {
+			for i < len(s) && !unicode.IsSpace(rune(s[i])) {
+				i++
+			}
+		}
+		tokens = append(tokens, s[start:i])
+	}
+	return tokens, nil
+}
+
+// ruleLiteral parses the literal token. If bare is true, other tokens are
+// strings.
+func ruleLiteral(token string, bare bool) (interface{}, error) {
+	switch token {
+	case "null":
+		return nil, nil
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if strings.HasPrefix(token, `"`) {
+		var s string
+		if err := json.Unmarshal([]byte(token), &s); err != nil {
+			return nil, fmt.Errorf("invalid string %s", token)
+		}
+		return s, nil
+	}
+	if (token[0] == '-' || '0' <= token[0] && token[0] <= '9') && json.Valid([]byte(token)) {
+		return json.Number(token), nil
+	}
+	if bare {
+		return token, nil
+	}
+	return nil, fmt.Errorf("invalid literal %s", token)
+}
+
+// rulePointer returns the JSON Pointer of the key, which is a JSON Pointer or
+// a dot-separated name.
+func rulePointer(key string) (string, error) {
+	if strings.HasPrefix(key, "/") {
+		return key, nil
+	}
+	if key == "" || strings.HasPrefix(key, `"`) {
+		return "", fmt.Errorf("invalid key %s", key)
+	}
+	var b strings.Builder
+	for _, name := range strings.Split(key, ".") {
+		if name == "" {
+			return "", fmt.Errorf("invalid key %s", key)
+		}
+		b.WriteString("/")
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(name))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,76 @@
+package json2csv
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRule(t *testing.T) {
+	row := func() KeyValue {
+		return KeyValue{
+			"/type":             "refund",
+			"/amount":           json.Number("12.50"),
+			"/country":          "",
+			"/billing/country":  "JP",
+			"/qty":              json.Number("3"),
+			"/note":             "x",
+			"/a.b":              "dot",
+			"/shipping/country": "US",
+		}
+	}
+
+	testCases := []struct {
+		rule    string
+		changed KeyValue // changed keys, nil value means removed
+	}{
+		{`if /type == refund then /amount := -/amount`, KeyValue{"/amount": json.Number("-12.50")}},
+		{`if type == "refund" then amount := -amount`, KeyValue{"/amount": json.Number("-12.50")}},
+		{`if /type != refund then /amount := -/amount`, KeyValue{}},
+		{`if /type == sale then /amount := 0`, KeyValue{}},
+		{`if country empty then country := billing.country`, KeyValue{"/country": "JP"}},
+		{`if shipping.country not empty then country := shipping.country`, KeyValue{"/country": "US"}},
+		{`if /missing empty then /flag := true`, KeyValue{"/flag": true}},
+		{`if /missing != 1 then /flag := "yes no"`, KeyValue{"/flag": "yes no"}},
+		{`if /qty == 3.0 then /qty := 4`, KeyValue{"/qty": json.Number("4")}},
+		{`/note := null`, KeyValue{"/note": nil}},
+		{`/note := /missing`, KeyValue{"/note": nil}},
+		{`/copy := /a.b`, KeyValue{"/copy": "dot"}},
+	}
+
+	for caseIndex, testCase := range testCases {
+		r, err := ParseRule(testCase.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := row()
+		for k, v := range testCase.changed {
+			if v == nil {
+				delete(expected, k)
+			} else {
+				expected[k] = v
+			}
+		}
+		actual := row()
+		if err := r.Apply(actual); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%d: Expected %v, but %v", caseIndex, expected, actual)
+		}
+	}
+
+	r, err := ParseRule(`/x := -/type`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Apply(row()); err == nil {
+		t.Error("Expected error for negating a string")
+	}
+
+	for _, rule := range []string{``, `if /a == 1 /b := 2`, `if /a then /b := 2`, `/a = 1`, `/a := b c`, `if /a == null then /b := 1`, `/a := "x`, `a..b := 1`} {
+		if _, err := ParseRule(rule); err == nil {
+			t.Errorf("Expected error for %q", rule)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package json2csv
+
+import "io"
+
+// StreamWriter writes CSV data record by record.
+//
+// Unlike CSVWriter, the header can't be determined from all records.
+// It is fixed by Columns, or by the keys of the first record.
+// Keys which are not in the header are passed to Dropped, and WriteRecord
+// fails with *DroppedKeysError if Dropped is nil.
+// PreviousHeader and ColumnsFirst are applied only if Columns and
+// AppendHeader are empty.
+type StreamWriter struct {
+	*CSVWriter
+
+	// AppendHeader is the header names of existing CSV data which the
+	// records are appended to, e.g. read from the output file. If it is set,
+	// the comments and the header are not written, and unless Columns is
+	// set, the columns are the ones of AppendHeader: the keys are matched to
+	// them by their header names, and the other keys are dropped.
+	AppendHeader []string
+
+	// Dropped is called with the values of the keys which are not in the
+	// header, before the record is written. The row number starts at 1.
+	// If it returns an error, the record is not written and WriteRecord
+	// returns the error.
+	Dropped func(row int, dropped KeyValue) error
+
+	index     *columnIndex
+	header    []string
+	unmatched map[string][]int // positions of AppendHeader without a key
+	format    formatFunc
+	rows      int
+}
+
+// NewStreamWriter returns new StreamWriter with JSONPointerStyle.
+func NewStreamWriter(w io.Writer) *StreamWriter {
+	return &StreamWriter{
+		CSVWriter: NewCSVWriter(w),
+	}
+}
+
+// WriteRecord writes a record, and the header before the first record.
+// The record is flushed immediately.
+func (w *StreamWriter) WriteRecord(kv KeyValue) error {
+	if w.index == nil {
+		if err := w.writeHeader(kv); err != nil {
+			return err
+		}
+	}
+
+	if err := w.matchKeys(kv); err != nil {
+		return err
+	}
+	if err := w.dropped(w.rows+1, kv); err != nil {
+		return err
+	}
+
+	w.rows++
+	record := w.index.Record(kv, w.format)
+	if err := w.limitCells(w.rows, w.index.keys, record); err != nil {
+		return err
+	}
+	if err := w.writeFields(record); err != nil {
+		return err
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// Keys returns the keys (JSON Pointers) of the header, or nil before the
+// first record. The keys of AppendHeader columns which no record has matched
+// yet are empty.
+func (w *StreamWriter) Keys() []string {
+	if w.index == nil {
+		return nil
+	}
+	return w.index.keys
+}
+
+func (w *StreamWriter) writeHeader(first KeyValue) error {
+	if len(w.AppendHeader) > 0 && len(w.Columns) == 0 {
+		w.header = w.AppendHeader
+		w.index = &columnIndex{
+			keys:     make([]string, len(w.header)),
+			position: map[string]int{},
+		}
+		w.unmatched = make(map[string][]int, len(w.header))
+		for i, name := range w.header {
+			w.unmatched[name] = append(w.unmatched[name], i)
+		}
+		w.format = w.formatter(w.index.keys, w.header)
+		return nil
+	}
+
+	keys, header, err := w.columns([]KeyValue{first})
+	if err != nil {
+		return err
+	}
+	w.index = newColumnIndex(keys)
+	w.header = header
+	w.format = w.formatter(keys, header)
+	if len(w.AppendHeader) > 0 {
+		return nil
+	}
+	return w.CSVWriter.writeHeader(keys, header)
+}
+
+// matchKeys matches the keys of the record to the AppendHeader columns which
+// have no key yet, by their header names.
+func (w *StreamWriter) matchKeys(kv KeyValue) error {
+	if len(w.unmatched) == 0 {
+		return nil
+	}
+	matched := false
+	for key := range kv {
+		if _, ok := w.index.position[key]; ok {
+			continue
+		}
+		pointer, err := w.pointerCache.Parse(key)
+		if err != nil {
+			return err
+		}
+		name := w.getHeader(pointers{pointer})[0]
+		p := w.unmatched[name]
+		if len(p) == 0 {
+			continue
+		}
+		w.index.keys[p[0]] = key
+		w.index.position[key] = p[0]
+		if len(p) == 1 {
+			delete(w.unmatched, name)
+		} else {
+			w.unmatched[name] = p[1:]
+		}
+		matched = true
+	}
+	if matched {
+		// ColumnQuoting etc. may refer to the keys.
+		w.format = w.formatter(w.index.keys, w.header)
+	}
+	return nil
+}
+
+// dropped reports the keys of the row which are not in the header.
+func (w *StreamWriter) dropped(row int, kv KeyValue) error {
+	var dropped KeyValue
+	for key, value := range kv {
+		if _, ok := w.index.position[key]; !ok {
+			if dropped == nil {
+				dropped = KeyValue{}
+			}
+			dropped[key] = value
+		}
+	}
+	if dropped == nil {
+		return nil
+	}
+	if w.Dropped == nil {
+		return &DroppedKeysError{Row: row, Keys: dropped.SortedKeys()}
+	}
+	return w.Dropped(row, dropped)
+}
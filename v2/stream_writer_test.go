@@ -0,0 +1,156 @@
+package json2csv_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yukithm/json2csv/v2"
+)
+
+func TestStreamWriter(t *testing.T) {
+	records := []json2csv.KeyValue{
+		{"/id": 1, "/name": "foo"},
+		{"/id": 2, "/name": "bar", "/extra": "dropped"},
+		{"/id": 3},
+	}
+
+	testCases := []struct {
+		columns []string
+		want    string
+		dropped string
+	}{
+		{nil, "/id,/name\n1,foo\n2,bar\n3,\n", "2:/extra=dropped;"},
+		{[]string{"/name", "/extra"}, "/name,/extra\nfoo,\nbar,dropped\n,\n", "1:/id=1;2:/id=2;3:/id=3;"},
+	}
+	for caseIndex, testCase := range testCases {
+		b := &bytes.Buffer{}
+		dropped := ""
+		wr := json2csv.NewStreamWriter(b)
+		wr.Columns = testCase.columns
+		wr.Dropped = func(row int, kv json2csv.KeyValue) error {
+			for _, key := range kv.SortedKeys() {
+				dropped += fmt.Sprintf("%d:%s=%v;", row, key, kv[key])
+			}
+			return nil
+		}
+		if keys := wr.Keys(); keys != nil {
+			t.Errorf("%d: Expected nil keys before the first record, but %v", caseIndex, keys)
+		}
+		for _, record := range records {
+			if err := wr.WriteRecord(record); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := b.String(); got != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, got)
+		}
+		if dropped != testCase.dropped {
+			t.Errorf("%d: Expected dropped %q, but %q", caseIndex, testCase.dropped, dropped)
+		}
+	}
+}
+
+func TestStreamWriterDroppedKeysError(t *testing.T) {
+	b := &bytes.Buffer{}
+	wr := json2csv.NewStreamWriter(b)
+	if err := wr.WriteRecord(json2csv.KeyValue{"/id": 1}); err != nil {
+		t.Fatal(err)
+	}
+	err := wr.WriteRecord(json2csv.KeyValue{"/id": 2, "/z": 1, "/a": 2})
+	var dropped *json2csv.DroppedKeysError
+	if !errors.As(err, &dropped) {
+		t.Fatalf("Expected DroppedKeysError, but %#v", err)
+	}
+	if dropped.Row != 2 || strings.Join(dropped.Keys, ",") != "/a,/z" {
+		t.Errorf("Expected row 2 and keys [/a /z], but %d %v", dropped.Row, dropped.Keys)
+	}
+	expected := "/id\n1\n"
+	if b.String() != expected {
+		t.Errorf("Expected %q, but %q", expected, b.String())
+	}
+}
+
+func TestStreamWriterAppendHeader(t *testing.T) {
+	records := []json2csv.KeyValue{
+		{"/id": 1},
+		{"/id": 2, "/user/name": "foo", "/extra": "dropped"},
+		{"/user/name": "bar"},
+	}
+
+	testCases := []struct {
+		columns []string
+		want    string
+		dropped string
+	}{
+		{nil, "1,,\n2,,foo\n,,bar\n", "2:/extra;"},
+		{[]string{"/user/name", "/id"}, ",1\nfoo,2\nbar,\n", "2:/extra;"},
+	}
+	for caseIndex, testCase := range testCases {
+		b := &bytes.Buffer{}
+		dropped := ""
+		wr := json2csv.NewStreamWriter(b)
+		wr.HeaderStyle = json2csv.DotNotationStyle
+		wr.Comments = []string{"not written"}
+		wr.AppendHeader = []string{"id", "gone", "user.name"}
+		wr.Columns = testCase.columns
+		wr.Dropped = func(row int, kv json2csv.KeyValue) error {
+			for _, key := range kv.SortedKeys() {
+				dropped += fmt.Sprintf("%d:%s;", row, key)
+			}
+			return nil
+		}
+		for _, record := range records {
+			if err := wr.WriteRecord(record); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := b.String(); got != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, got)
+		}
+		if dropped != testCase.dropped {
+			t.Errorf("%d: Expected dropped %q, but %q", caseIndex, testCase.dropped, dropped)
+		}
+	}
+}
+
+func TestTransposeWriter(t *testing.T) {
+	records := []json2csv.KeyValue{
+		{"/id": 1, "/name": "foo"},
+		{"/id": 2, "/name": "", "/extra": "late"},
+		{"/id": 3, "/name": "a,b"},
+	}
+
+	testCases := []struct {
+		bufferSize int
+		columns    []string
+		want       string
+	}{
+		{0, nil, "/extra,,late,\n/id,1,2,3\n/name,foo,\"\",\"a,b\"\n"},
+		{1, nil, "/extra,,late,\n/id,1,2,3\n/name,foo,\"\",\"a,b\"\n"},
+		{8, []string{"/id", "/name"}, "/id,1,2,3\n/name,foo,\"\",\"a,b\"\n"},
+	}
+	for caseIndex, testCase := range testCases {
+		b := &bytes.Buffer{}
+		wr := json2csv.NewTransposeWriter(b)
+		wr.QuoteEmpty = true
+		wr.BufferSize = testCase.bufferSize
+		wr.Columns = testCase.columns
+		for _, record := range records {
+			if err := wr.WriteRecord(record); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if b.Len() != 0 {
+			t.Errorf("%d: Expected no output before Close, but %q", caseIndex, b.String())
+		}
+		if err := wr.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, got)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package json2csv
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Transform rewrites the string values of a column, e.g. to normalize the
+// values used as keys for matching.
+type Transform func(s string) string
+
+// Transforms of the case and the accents.
+var (
+	// UpperCase maps the letters to upper case.
+	UpperCase Transform = strings.ToUpper
+
+	// LowerCase maps the letters to lower case.
+	LowerCase Transform = strings.ToLower
+
+	// TitleCase maps the first letter of each word to title case and the
+	// rest to lower case.
+	TitleCase Transform = func(s string) string {
+		return cases.Title(language.Und).String(s)
+	}
+
+	// StripAccents removes the diacritical marks, e.g. "é" to "e".
+	StripAccents Transform = func(s string) string {
+		t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+		result, _, err := transform.String(t, s)
+		if err != nil {
+			return s
+		}
+		return result
+	}
+)
+
+// ReplaceTransform replaces the matches of re with repl, which can refer to
+// the submatches like $1 or ${name} (see regexp.Regexp.ReplaceAllString).
+func ReplaceTransform(re *regexp.Regexp, repl string) Transform {
+	return func(s string) string {
+		return re.ReplaceAllString(s, repl)
+	}
+}
+
+// ExtractTransform returns the submatch of the group (0 means the whole
+// match) of the first match of re, or "" if re doesn't match.
+func ExtractTransform(re *regexp.Regexp, group int) Transform {
+	return func(s string) string {
+		m := re.FindStringSubmatch(s)
+		if m == nil {
+			return ""
+		}
+		return m[group]
+	}
+}
+
+// ParseTransform parses the transform spec:
+//   - "upper", "lower", "title" or "unaccent"
+//   - "replace:/PATTERN/REPLACEMENT/" replaces the matches of the regular
+//     expression, e.g. "replace:/\s+/ /"
+//   - "extract:/PATTERN/" extracts the first group (or the whole match if
+//     there are no groups), e.g. "extract:/@(.+)$/" for the domain of an
+//     email address
+//   - "extract:/PATTERN/GROUP" extracts the group by number or name
+//
+// The first character after "replace:" or "extract:" is the delimiter (an
+// ASCII character), which can be escaped by a backslash in PATTERN and
+// REPLACEMENT.
+func ParseTransform(spec string) (Transform, error) {
+	for _, name := range []string{"replace", "extract"} {
+		if prefix := name + ":"; strings.HasPrefix(spec, prefix) && len(spec) > len(prefix) {
+			t, err := parseRegexpTransform(name, spec[len(prefix):])
+			if err != nil {
+				return nil, fmt.Errorf("Invalid transform %q: %s", spec, err)
+			}
+			return t, nil
+		}
+	}
+
+	switch spec {
+	case "upper":
+		return UpperCase, nil
+	case "lower":
+		return LowerCase, nil
+	case "title":
+		return TitleCase, nil
+	case "unaccent":
+		return StripAccents, nil
+	}
+	return nil, fmt.Errorf("Invalid transform %q", spec)
+}
+
+// parseRegexpTransform parses the rest of the spec of the replace or extract
+// transform, which starts with the delimiter.
+func parseRegexpTransform(name string, s string) (Transform, error) {
+	if s[0] >= utf8.RuneSelf || s[0] == '\\' {
+		return nil, fmt.Errorf("invalid delimiter")
+	}
+	parts := splitDelimited(s)
+	if name == "replace" && (len(parts) != 3 || parts[2] != "") {
+		return nil, fmt.Errorf("expected /PATTERN/REPLACEMENT/")
+	} else if name == "extract" && len(parts) != 2 {
+		return nil, fmt.Errorf("expected /PATTERN/[GROUP]")
+	}
+	re, err := regexp.Compile(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	if name == "replace" {
+		return ReplaceTransform(re, parts[1]), nil
+	}
+
+	group := 0
+	if re.NumSubexp() > 0 {
+		group = 1
+	}
+	if g := parts[1]; g != "" {
+		if n, err := strconv.Atoi(g); err == nil {
+			group = n
+		} else {
+			group = re.SubexpIndex(g)
+		}
+		if group < 0 || group > re.NumSubexp() {
+			return nil, fmt.Errorf("no group %q", g)
+		}
+	}
+	return ExtractTransform(re, group), nil
+}
+
+// splitDelimited splits s by its first character. The delimiter escaped by a
+// backslash is not a separator, and the backslash is removed.
+// E.g. "/a\/b/c/" is ["a/b", "c", ""].
+func splitDelimited(s string) []string {
+	delim := s[0]
+	var parts []string
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == delim:
+			b.WriteByte(delim)
+			i++
+		case s[i] == delim:
+			parts = append(parts, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return append(parts, b.String())
+}
+
+// columnTransforms returns the transforms of each column by
+// ColumnTransforms. Header names take precedence over keys.
+func (w *CSVWriter) columnTransforms(keys []string, header []string) [][]Transform {
+	transforms := make([][]Transform, len(keys))
+	if len(w.ColumnTransforms) == 0 {
+		return transforms
+	}
+	for i := range keys {
+		if t, ok := w.ColumnTransforms[header[i]]; ok {
+			transforms[i] = t
+		} else if t, ok := w.ColumnTransforms[keys[i]]; ok && keys[i] != "" {
+			transforms[i] = t
+		}
+	}
+	return transforms
+}
@@ -0,0 +1,64 @@
+package json2csv
+
+import "testing"
+
+func TestParseTransform(t *testing.T) {
+	testCases := []struct {
+		spec     string
+		input    string
+		expected string
+	}{
+		{"upper", "Crème brûlée", "CRÈME BRÛLÉE"},
+		{"lower", "Crème BRÛLÉE", "crème brûlée"},
+		{"title", "crème BRÛLÉE o'neil", "Crème Brûlée O'neil"},
+		{"unaccent", "Crème brûlée, Ångström, São Paulo", "Creme brulee, Angstrom, Sao Paulo"},
+	}
+
+	for caseIndex, testCase := range testCases {
+		tr, err := ParseTransform(testCase.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := tr(testCase.input); actual != testCase.expected {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.expected, actual)
+		}
+	}
+
+	if _, err := ParseTransform("reverse"); err == nil {
+		t.Error("Expected error for reverse")
+	}
+}
+
+func TestRegexpTransform(t *testing.T) {
+	testCases := []struct {
+		spec     string
+		input    string
+		expected string
+	}{
+		{`replace:/\s+/ /`, "a  b\t\tc", "a b c"},
+		{`replace:/(\w+)@(\w+)/$2:$1/`, "foo@bar", "bar:foo"},
+		{`replace:|/+|/|`, "a//b///c", "a/b/c"},
+		{`replace:/\//-/`, "a/b", "a-b"},
+		{`extract:/@(.+)$/`, "foo@example.com", "example.com"},
+		{`extract:/@(.+)$/`, "invalid", ""},
+		{`extract:/\d+/`, "order 123 of 456", "123"},
+		{`extract:/(\d+)-(\d+)/2`, "10-20", "20"},
+		{`extract:/(?P<user>[^@]+)@(?P<domain>.+)/domain`, "foo@example.com", "example.com"},
+	}
+
+	for caseIndex, testCase := range testCases {
+		tr, err := ParseTransform(testCase.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := tr(testCase.input); actual != testCase.expected {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.expected, actual)
+		}
+	}
+
+	for _, spec := range []string{"replace:", "replace:/a/", "replace:/a/b/c", "replace:/(/x/", "extract:/a/b/", "extract:/(a)/2", "extract:/(a)/x", "replace:éaébé"} {
+		if _, err := ParseTransform(spec); err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+}
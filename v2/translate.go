@@ -0,0 +1,62 @@
+package json2csv
+
+import "strings"
+
+// Translator translates header names, e.g. into the language of the end
+// user. Implementations can wrap a message catalog such as a go-i18n bundle.
+type Translator interface {
+	// Translate returns the translated name of the column of the key (JSON
+	// Pointer), whose name in the header style is name. It returns false to
+	// keep the name.
+	Translate(key string, name string) (string, bool)
+}
+
+// TranslatorFunc adapts a function to Translator.
+type TranslatorFunc func(key string, name string) (string, bool)
+
+// Translate calls f(key, name).
+func (f TranslatorFunc) Translate(key string, name string) (string, bool) {
+	return f(key, name)
+}
+
+// TranslationMap translates header names by the keys (JSON Pointers) or the
+// names in the header style, e.g. {"/amount": "Montant"}.
+// Keys take precedence over names.
+type TranslationMap map[string]string
+
+// Translate returns the translation of the key or the name.
+func (m TranslationMap) Translate(key string, name string) (string, bool) {
+	if t, ok := m[key]; ok {
+		return t, true
+	}
+	t, ok := m[name]
+	return t, ok
+}
+
+// Translations is the TranslationMaps by locale (e.g. "fr" or "pt-BR").
+type Translations map[string]TranslationMap
+
+// Lookup returns the TranslationMap of the locale. If there is no map of the
+// locale, the map of its language ("pt" for "pt-BR" or "pt_BR") is returned.
+func (t Translations) Lookup(locale string) (TranslationMap, bool) {
+	if m, ok := t[locale]; ok {
+		return m, true
+	}
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		m, ok := t[locale[:i]]
+		return m, ok
+	}
+	return nil, false
+}
+
+// translateHeader translates the header names in place.
+func translateHeader(translator Translator, keys []string, header []string) {
+	if translator == nil {
+		return
+	}
+	for i, name := range header {
+		if t, ok := translator.Translate(keys[i], name); ok {
+			header[i] = t
+		}
+	}
+}
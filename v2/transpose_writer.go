@@ -0,0 +1,281 @@
+package json2csv
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// defaultTransposeBufferSize is the default of TransposeWriter.BufferSize.
+const defaultTransposeBufferSize = 4 << 20
+
+var errCorruptSpool = errors.New("json2csv: corrupt spool")
+
+// TransposeWriter writes transposed CSV data record by record, without
+// holding all records in memory.
+//
+// The records are spooled to a temporary file, so that the header has the
+// keys of all records, or is fixed by Columns. Close reads them back and
+// buffers the formatted cells by column, which are spooled to another
+// temporary file when the buffer exceeds BufferSize. Then it writes the
+// columns as rows by reading them back, so only one output row (a cell per
+// record) is held at a time.
+type TransposeWriter struct {
+	*CSVWriter
+
+	// TempDir is the directory of the temporary files (os.TempDir() if empty).
+	TempDir string
+
+	// BufferSize is the size of the cells held in memory before they are
+	// spooled to the temporary file (4MB if 0).
+	BufferSize int
+
+	records  *RecordSpool
+	keys     []string
+	header   []string
+	index    *columnIndex
+	format   formatFunc
+	rows     int
+	buffers  [][]byte // encoded cells of each column not spooled yet
+	buffered int
+	spool    *os.File
+	size     int64
+	blocks   [][]spoolBlock // spooled cells of each column
+}
+
+// spoolBlock is a range of the spool file.
+type spoolBlock struct {
+	offset int64
+	size   int
+}
+
+// NewTransposeWriter returns new TransposeWriter with JSONPointerStyle.
+func NewTransposeWriter(w io.Writer) *TransposeWriter {
+	return &TransposeWriter{
+		CSVWriter: NewCSVWriter(w),
+	}
+}
+
+// WriteRecord adds a record, which becomes a column of the output.
+func (w *TransposeWriter) WriteRecord(kv KeyValue) error {
+	if w.records == nil {
+		w.records = &RecordSpool{TempDir: w.TempDir}
+	}
+	return w.records.Add(kv)
+}
+
+// Keys returns the keys (JSON Pointers) of the header of the records so
+// far, or nil before the first record.
+func (w *TransposeWriter) Keys() []string {
+	if w.records == nil || w.records.Len() == 0 {
+		return nil
+	}
+	keys, _, err := w.columns([]KeyValue{w.records.keySet()})
+	if err != nil {
+		return nil
+	}
+	return keys
+}
+
+// Close writes the transposed CSV data and removes the temporary files.
+// Nothing is written if there are no records. It doesn't close the
+// underlying io.Writer. Call it even if WriteRecord fails, so that the
+// temporary files are removed.
+func (w *TransposeWriter) Close() error {
+	defer w.removeSpool()
+	if w.records == nil || w.records.Len() == 0 {
+		return nil
+	}
+
+	keys, header, err := w.columns([]KeyValue{w.records.keySet()})
+	if err != nil {
+		return err
+	}
+	w.begin(keys, header)
+	if err := w.records.Each(w.addRecord); err != nil {
+		return err
+	}
+	// The records are no longer needed.
+	w.records.Close()
+	return w.writeColumns()
+}
+
+// begin starts buffering the cells of the columns.
+func (w *TransposeWriter) begin(keys []string, header []string) {
+	w.keys, w.header = keys, header
+	w.index = newColumnIndex(keys)
+	w.format = w.formatter(keys, header)
+	w.buffers = make([][]byte, len(keys))
+	w.blocks = make([][]spoolBlock, len(keys))
+}
+
+// addRecord buffers the cells of the record as a column of the output.
+func (w *TransposeWriter) addRecord(kv KeyValue) error {
+	w.rows++
+	record := w.index.Record(kv, w.format)
+	if err := w.limitCells(w.rows, w.keys, record); err != nil {
+		return err
+	}
+	for i, f := range record {
+		if f == (field{}) {
+			continue
+		}
+		n := len(w.buffers[i])
+		w.buffers[i] = appendSpoolCell(w.buffers[i], w.rows-1, f)
+		w.buffered += len(w.buffers[i]) - n
+	}
+
+	bufferSize := w.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultTransposeBufferSize
+	}
+	if w.buffered >= bufferSize {
+		return w.spoolBuffers()
+	}
+	return nil
+}
+
+// writeColumns writes the buffered columns as rows.
+func (w *TransposeWriter) writeColumns() error {
+	if err := w.writeComments(); err != nil {
+		return err
+	}
+	rows, err := w.headerRows(w.keys, w.header)
+	if err != nil {
+		return err
+	}
+
+	record := make([]field, w.rows+len(rows))
+	var block []byte
+	for i := range w.keys {
+		for j := range record {
+			record[j] = field{}
+		}
+		for j, row := range rows {
+			record[j] = field{value: row[i]}
+		}
+		cells := record[len(rows):]
+		for _, b := range w.blocks[i] {
+			if cap(block) < b.size {
+				block = make([]byte, b.size)
+			}
+			block = block[:b.size]
+			if _, err := w.spool.ReadAt(block, b.offset); err != nil {
+				return err
+			}
+			if err := decodeSpoolCells(block, cells); err != nil {
+				return err
+			}
+		}
+		if err := decodeSpoolCells(w.buffers[i], cells); err != nil {
+			return err
+		}
+		// Release the column as soon as it is decoded.
+		w.buffers[i] = nil
+
+		if err := w.writeFields(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// spoolBuffers appends the buffered cells to the temporary file.
+func (w *TransposeWriter) spoolBuffers() error {
+	if w.spool == nil {
+		f, err := ioutil.TempFile(w.TempDir, "json2csv-transpose-")
+		if err != nil {
+			return err
+		}
+		w.spool = f
+	}
+
+	for i, buf := range w.buffers {
+		if len(buf) == 0 {
+			continue
+		}
+		if _, err := w.spool.Write(buf); err != nil {
+			return err
+		}
+		w.blocks[i] = append(w.blocks[i], spoolBlock{offset: w.size, size: len(buf)})
+		w.size += int64(len(buf))
+		w.buffers[i] = buf[:0]
+	}
+	w.buffered = 0
+	return nil
+}
+
+func (w *TransposeWriter) removeSpool() {
+	if w.records != nil {
+		w.records.Close()
+	}
+	if w.spool == nil {
+		return
+	}
+	w.spool.Close()
+	os.Remove(w.spool.Name())
+	w.spool = nil
+}
+
+// Flags of the encoded cells.
+const (
+	spoolQuote   = 1 << iota // field.quote
+	spoolQuoting             // field.quoting follows
+)
+
+// appendSpoolCell appends the encoded cell of the row to buf: the row, the
+// flags, the quoting (if any), the length of the value and the value.
+func appendSpoolCell(buf []byte, row int, f field) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	buf = append(buf, tmp[:binary.PutUvarint(tmp[:], uint64(row))]...)
+	var flags byte
+	if f.quote {
+		flags |= spoolQuote
+	}
+	if f.quoting != nil {
+		flags |= spoolQuoting
+	}
+	buf = append(buf, flags)
+	if f.quoting != nil {
+		buf = append(buf, byte(*f.quoting))
+	}
+	buf = append(buf, tmp[:binary.PutUvarint(tmp[:], uint64(len(f.value)))]...)
+	return append(buf, f.value...)
+}
+
+// decodeSpoolCells decodes the cells in buf into the fields by the rows.
+func decodeSpoolCells(buf []byte, cells []field) error {
+	for len(buf) > 0 {
+		row, n := binary.Uvarint(buf)
+		if n <= 0 || row >= uint64(len(cells)) || len(buf) < n+1 {
+			return errCorruptSpool
+		}
+		buf = buf[n:]
+
+		var f field
+		flags := buf[0]
+		buf = buf[1:]
+		f.quote = flags&spoolQuote != 0
+		if flags&spoolQuoting != 0 {
+			if len(buf) == 0 {
+				return errCorruptSpool
+			}
+			quoting := QuoteStyle(buf[0])
+			f.quoting = &quoting
+			buf = buf[1:]
+		}
+
+		size, n := binary.Uvarint(buf)
+		if n <= 0 || uint64(len(buf)-n) < size {
+			return errCorruptSpool
+		}
+		f.value = string(buf[n : n+int(size)])
+		buf = buf[n+int(size):]
+		cells[row] = f
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package json2csv
+
+import (
+	"fmt"
+	"reflect"
+)
+
+func valueOf(obj interface{}) reflect.Value {
+	v, ok := obj.(reflect.Value)
+	if !ok {
+		v = reflect.ValueOf(obj)
+	}
+
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+func toString(obj interface{}) string {
+	return fmt.Sprintf("%v", obj)
+}
+
+// maxSafeDigits is the number of digits which spreadsheets keep precisely.
+const maxSafeDigits = 15
+
+// isNumericID reports whether s is a string of digits which spreadsheets
+// would mangle as a number: with leading zeros or too many digits.
+func isNumericID(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return (len(s) > 1 && s[0] == '0') || len(s) > maxSafeDigits
+}
+
+// isHex reports whether s consists of hex digits only.
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
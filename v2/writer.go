@@ -0,0 +1,295 @@
+package json2csv
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	errInvalidDelim      = errors.New("csv: invalid field or comment delimiter")
+	errInvalidTerminator = errors.New("csv: invalid record terminator")
+)
+
+// QuoteStyle represents when fields are quoted.
+type QuoteStyle uint
+
+// Quote style
+const (
+	// Quote fields only if needed (same as encoding/csv).
+	QuoteMinimal QuoteStyle = iota
+
+	// Quote all fields.
+	QuoteAll
+
+	// Don't quote fields, unless they would break the record: fields with
+	// the delimiter or the terminator (or, without Terminator, a line break
+	// or a quote) fall back to quoting.
+	QuoteNone
+)
+
+// Dialect is a set of the delimiters and the quoting.
+type Dialect struct {
+	Comma      rune
+	Terminator string // empty means LF (or CRLF if UseCRLF)
+	Quoting    QuoteStyle
+}
+
+// Predefined dialects
+var (
+	// Comma separated values.
+	CSVDialect = Dialect{Comma: ',', Quoting: QuoteMinimal}
+
+	// Tab separated values.
+	TSVDialect = Dialect{Comma: '\t', Quoting: QuoteMinimal}
+
+	// ASCII unit separator (0x1F) and record separator (0x1E), without
+	// quoting. Fields may contain commas, quotes and line breaks as is.
+	ASCIIDialect = Dialect{Comma: '\x1f', Terminator: "\x1e", Quoting: QuoteNone}
+)
+
+// field is a formatted value of a cell.
+type field struct {
+	value   string
+	quote   bool        // always quoted
+	quoting *QuoteStyle // overrides Writer.Quoting if not nil
+}
+
+// Writer writes CSV records.
+//
+// It is compatible with encoding/csv.Writer, and additionally can quote
+// fields regardless of their content.
+type Writer struct {
+	Comma   rune // Field delimiter (set to ',' by NewWriter)
+	UseCRLF bool // True to use \r\n as the line terminator
+
+	// Terminator is the record terminator (e.g. "\x1e" or "\x00").
+	// If it is set, it is used instead of the line terminator, and line
+	// breaks in fields are written as is regardless of UseCRLF.
+	Terminator string
+
+	// Quoting specifies when fields are quoted. Fields marked to be quoted
+	// (e.g. CSVWriter.QuoteEmpty) are quoted regardless of it.
+	Quoting QuoteStyle
+
+	w *bufio.Writer
+}
+
+// NewWriter returns a new Writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		Comma: ',',
+		w:     bufio.NewWriter(w),
+	}
+}
+
+// SetDialect sets the delimiters and the quoting.
+func (w *Writer) SetDialect(d Dialect) {
+	w.Comma = d.Comma
+	w.Terminator = d.Terminator
+	w.Quoting = d.Quoting
+}
+
+// Reset discards any unflushed data and switches the output to out.
+// The settings are kept.
+func (w *Writer) Reset(out io.Writer) {
+	w.w.Reset(out)
+}
+
+// Write writes a single CSV record along with any necessary quoting.
+// Writes are buffered, so Flush must eventually be called.
+func (w *Writer) Write(record []string) error {
+	fields := make([]field, len(record))
+	for i, value := range record {
+		fields[i].value = value
+	}
+	return w.writeFields(fields)
+}
+
+// WriteComment writes the comment line with the prefix (e.g. "# ").
+// Each line of a multi-line comment is written as a comment line.
+// Comments are not part of RFC 4180, and readers must be told to skip them.
+func (w *Writer) WriteComment(prefix string, comment string) error {
+	for _, line := range strings.Split(strings.Replace(comment, "\r\n", "\n", -1), "\n") {
+		if _, err := w.w.WriteString(prefix + line); err != nil {
+			return err
+		}
+		if err := w.writeTerminator(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteAll writes multiple CSV records using Write and then calls Flush.
+func (w *Writer) WriteAll(records [][]string) error {
+	for _, record := range records {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	return w.w.Flush()
+}
+
+// Flush writes any buffered data to the underlying io.Writer.
+// To check if an error occurred during the Flush, call Error.
+func (w *Writer) Flush() {
+	w.w.Flush()
+}
+
+// Error reports any error that has occurred during a previous Write or Flush.
+func (w *Writer) Error() error {
+	_, err := w.w.Write(nil)
+	return err
+}
+
+func (w *Writer) writeFields(fields []field) error {
+	if !validDelim(w.Comma) {
+		return errInvalidDelim
+	}
+	if strings.ContainsRune(w.Terminator, w.Comma) || strings.ContainsRune(w.Terminator, '"') {
+		return errInvalidTerminator
+	}
+
+	for n, f := range fields {
+		if n > 0 {
+			if _, err := w.w.WriteRune(w.Comma); err != nil {
+				return err
+			}
+		}
+
+		if !f.quote && !w.shouldQuote(f.value, f.quoting) {
+			if _, err := w.w.WriteString(f.value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := w.writeQuoted(f.value); err != nil {
+			return err
+		}
+	}
+
+	return w.writeTerminator()
+}
+
+func (w *Writer) writeTerminator() error {
+	var err error
+	if w.Terminator != "" {
+		_, err = w.w.WriteString(w.Terminator)
+	} else if w.UseCRLF {
+		_, err = w.w.WriteString("\r\n")
+	} else {
+		err = w.w.WriteByte('\n')
+	}
+	return err
+}
+
+func (w *Writer) writeQuoted(value string) error {
+	if err := w.w.WriteByte('"'); err != nil {
+		return err
+	}
+	for len(value) > 0 {
+		// Copy verbatim everything before the special character.
+		i := strings.IndexAny(value, "\"\r\n")
+		if i < 0 {
+			i = len(value)
+		}
+		if _, err := w.w.WriteString(value[:i]); err != nil {
+			return err
+		}
+		value = value[i:]
+
+		// Encode the special character.
+		if len(value) > 0 {
+			var err error
+			switch value[0] {
+			case '"':
+				_, err = w.w.WriteString(`""`)
+			case '\r':
+				if !w.UseCRLF || w.Terminator != "" {
+					err = w.w.WriteByte('\r')
+				}
+			case '\n':
+				if w.UseCRLF && w.Terminator == "" {
+					_, err = w.w.WriteString("\r\n")
+				} else {
+					err = w.w.WriteByte('\n')
+				}
+			}
+			value = value[1:]
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return w.w.WriteByte('"')
+}
+
+// shouldQuote reports whether the field is quoted by the quoting style.
+// The style of the field is used instead of Writer.Quoting if it is set.
+func (w *Writer) shouldQuote(value string, quoting *QuoteStyle) bool {
+	style := w.Quoting
+	if quoting != nil {
+		style = *quoting
+	}
+	switch style {
+	case QuoteAll:
+		return true
+	case QuoteNone:
+		return w.fieldBreaksRecord(value)
+	default:
+		return w.fieldNeedsQuotes(value)
+	}
+}
+
+// fieldNeedsQuotes reports whether our field must be enclosed in quotes.
+// Same rules as encoding/csv: fields with a Comma, a quote, a line break,
+// a leading space, or `\.` (which PostgreSQL treats as end of data) are quoted.
+// Fields with the record Terminator are also quoted.
+func (w *Writer) fieldNeedsQuotes(value string) bool {
+	if value == "" {
+		return false
+	}
+	if value == `\.` {
+		return true
+	}
+	if w.Terminator != "" && strings.Contains(value, w.Terminator) {
+		return true
+	}
+
+	if w.Comma < utf8.RuneSelf {
+		for i := 0; i < len(value); i++ {
+			c := value[i]
+			if c == '\n' || c == '\r' || c == '"' || c == byte(w.Comma) {
+				return true
+			}
+		}
+	} else {
+		if strings.ContainsRune(value, w.Comma) || strings.ContainsAny(value, "\"\r\n") {
+			return true
+		}
+	}
+
+	r1, _ := utf8.DecodeRuneInString(value)
+	return unicode.IsSpace(r1)
+}
+
+// fieldBreaksRecord reports whether the unquoted field would be read as
+// another field or record, or as a quoted field.
+func (w *Writer) fieldBreaksRecord(value string) bool {
+	if strings.ContainsRune(value, w.Comma) {
+		return true
+	}
+	if w.Terminator != "" {
+		return strings.Contains(value, w.Terminator) || strings.HasPrefix(value, `"`)
+	}
+	return strings.ContainsAny(value, "\"\r\n")
+}
+
+func validDelim(r rune) bool {
+	return r != 0 && r != '"' && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
+}
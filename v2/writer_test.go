@@ -0,0 +1,138 @@
+package json2csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+var testWriterRecords = [][]string{
+	{"abc", "", "x y"},
+	{" leading space", "trailing space ", "comma,inside"},
+	{`quote"inside`, "line\nbreak", "carriage\rreturn"},
+	{`\.`, "crlf\r\ninside", "日本語"},
+	{""},
+}
+
+func TestWriterCompatibility(t *testing.T) {
+	for _, comma := range []rune{',', '\t', ';', '→'} {
+		for _, useCRLF := range []bool{false, true} {
+			var want, got bytes.Buffer
+			cw := csv.NewWriter(&want)
+			cw.Comma = comma
+			cw.UseCRLF = useCRLF
+			w := NewWriter(&got)
+			w.Comma = comma
+			w.UseCRLF = useCRLF
+
+			if err := cw.WriteAll(testWriterRecords); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteAll(testWriterRecords); err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != want.String() {
+				t.Errorf("%q/%v: Expected %q, but %q", comma, useCRLF, want.String(), got.String())
+			}
+		}
+	}
+}
+
+func TestWriterInvalidDelim(t *testing.T) {
+	w := NewWriter(&bytes.Buffer{})
+	w.Comma = '"'
+	if err := w.Write([]string{"a"}); err != errInvalidDelim {
+		t.Errorf("Expected %v, but %v", errInvalidDelim, err)
+	}
+}
+
+func TestWriteFieldsQuote(t *testing.T) {
+	var b bytes.Buffer
+	w := NewWriter(&b)
+	fields := []field{{value: "", quote: true}, {value: ""}, {value: "abc", quote: true}, {value: "a\"b", quote: true}}
+	if err := w.writeFields(fields); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+
+	want := `"",,"abc","a""b"` + "\n"
+	if got := b.String(); got != want {
+		t.Errorf("Expected %q, but %q", want, got)
+	}
+}
+
+func TestWriterTerminator(t *testing.T) {
+	testCases := []struct {
+		terminator string
+		useCRLF    bool
+		want       string
+	}{
+		{"\x1e", false, "a,\"b\x1ec\"\x1e\"d\ne\",f\x1e"},
+		{"\x00", true, "a,\"b\x00c\"\x00\"d\ne\",f\x00"},
+	}
+	for caseIndex, testCase := range testCases {
+		var b bytes.Buffer
+		w := NewWriter(&b)
+		w.Terminator = testCase.terminator
+		w.UseCRLF = testCase.useCRLF
+		if err := w.WriteAll([][]string{{"a", "b" + testCase.terminator + "c"}, {"d\ne", "f"}}); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, got)
+		}
+	}
+
+	w := NewWriter(&bytes.Buffer{})
+	w.Terminator = ","
+	if err := w.Write([]string{"a"}); err != errInvalidTerminator {
+		t.Errorf("Expected %v, but %v", errInvalidTerminator, err)
+	}
+}
+
+func TestWriterDialect(t *testing.T) {
+	record := []string{"a,b", "c\"d", "e\nf", ""}
+	testCases := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{CSVDialect, "\"a,b\",\"c\"\"d\",\"e\nf\",\n"},
+		{TSVDialect, "a,b\t\"c\"\"d\"\t\"e\nf\"\t\n"},
+		{ASCIIDialect, "a,b\x1fc\"d\x1fe\nf\x1f\x1e"},
+		{Dialect{Comma: ';', Quoting: QuoteAll}, "\"a,b\";\"c\"\"d\";\"e\nf\";\"\"\n"},
+	}
+	for caseIndex, testCase := range testCases {
+		var b bytes.Buffer
+		w := NewWriter(&b)
+		w.SetDialect(testCase.dialect)
+		if err := w.WriteAll([][]string{record}); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, got)
+		}
+	}
+}
+
+func TestWriterQuoteNone(t *testing.T) {
+	testCases := []struct {
+		dialect Dialect
+		record  []string
+		want    string
+	}{
+		{Dialect{Comma: ',', Quoting: QuoteNone}, []string{"a", " b", `\.`}, "a, b,\\.\n"},
+		{Dialect{Comma: ',', Quoting: QuoteNone}, []string{"a,b", "c\"d", "e\nf"}, "\"a,b\",\"c\"\"d\",\"e\nf\"\n"},
+		{ASCIIDialect, []string{"a\x1fb", "c\x1ed", "\"e", "f\"g"}, "\"a\x1fb\"\x1f\"c\x1ed\"\x1f\"\"\"e\"\x1ff\"g\x1e"},
+	}
+	for caseIndex, testCase := range testCases {
+		var b bytes.Buffer
+		w := NewWriter(&b)
+		w.SetDialect(testCase.dialect)
+		if err := w.WriteAll([][]string{testCase.record}); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != testCase.want {
+			t.Errorf("%d: Expected %q, but %q", caseIndex, testCase.want, got)
+		}
+	}
+}
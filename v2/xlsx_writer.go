@@ -0,0 +1,418 @@
+package json2csv
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ColumnType represents the cell type of a column in XLSX.
+type ColumnType uint
+
+// Column type
+const (
+	// Numbers and booleans in JSON are numbers and booleans, others are text.
+	GeneralType ColumnType = iota
+
+	// Always text, e.g. IDs and phone numbers which spreadsheets would
+	// convert to numbers in scientific notation.
+	TextType
+
+	// Numbers, including strings which look like numbers.
+	NumberType
+
+	// Dates, from strings in RFC 3339 or "2006-01-02" format.
+	DateType
+)
+
+// Cell styles in styles.xml
+const (
+	generalCellStyle = iota
+	textCellStyle
+	dateCellStyle
+	dateTimeCellStyle
+)
+
+// Limits of an Excel worksheet.
+const (
+	xlsxMaxRows    = 1048576
+	xlsxMaxColumns = 16384
+)
+
+// excelEpoch is the day zero of Excel serial dates (1900 date system).
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// XLSXRecordWriter is a RecordWriter which writes an Excel workbook with a
+// single worksheet. The rows are written as they come.
+//
+// CSVWriter.WriteRecords writes the values with their types through it,
+// so numbers and booleans in JSON are numbers and booleans. Records given to
+// WriteRecord are strings, which are text unless ColumnTypes says otherwise.
+// Numbers of more than 15 significant digits are written as text, since
+// Excel would round them. Rows and columns beyond the limits of a worksheet
+// (1048576 rows and 16384 columns) fail with *XLSXLimitError.
+type XLSXRecordWriter struct {
+	// SheetName is the name of the worksheet ("Sheet1" by default).
+	SheetName string
+
+	// ColumnTypes is the cell types of the columns by position.
+	ColumnTypes []ColumnType
+
+	// NamedColumnTypes specifies the cell types of the columns by header
+	// names or keys (JSON Pointers), which take precedence over ColumnTypes.
+	// It is applied to the values written by CSVWriter.WriteRecords.
+	// Values which can't be converted to the type are written as text.
+	NamedColumnTypes map[string]ColumnType
+
+	w     io.Writer
+	z     *zip.Writer
+	bw    *bufio.Writer
+	row   int
+	types []ColumnType // types of the columns by WriteKeyedHeader
+}
+
+// NewXLSXRecordWriter returns new XLSXRecordWriter.
+func NewXLSXRecordWriter(w io.Writer) *XLSXRecordWriter {
+	return &XLSXRecordWriter{
+		SheetName: "Sheet1",
+		w:         w,
+	}
+}
+
+// WriteHeader starts the workbook and writes the header row.
+func (w *XLSXRecordWriter) WriteHeader(header []string) error {
+	if err := w.startRow(len(header)); err != nil {
+		return err
+	}
+	for i, name := range header {
+		writeStringCell(w.bw, cellRef(i, w.row), name, generalCellStyle)
+	}
+	return w.endRow()
+}
+
+// WriteKeyedHeader resolves the cell types of the columns and writes the
+// header row.
+func (w *XLSXRecordWriter) WriteKeyedHeader(keys []string, header []string) error {
+	w.types = make([]ColumnType, len(keys))
+	for i := range keys {
+		if i < len(w.ColumnTypes) {
+			w.types[i] = w.ColumnTypes[i]
+		}
+		if t, ok := w.NamedColumnTypes[header[i]]; ok {
+			w.types[i] = t
+		} else if t, ok := w.NamedColumnTypes[keys[i]]; ok && keys[i] != "" {
+			w.types[i] = t
+		}
+	}
+	return w.WriteHeader(header)
+}
+
+// WriteRecord writes a row. Empty strings are empty cells.
+func (w *XLSXRecordWriter) WriteRecord(record []string) error {
+	if err := w.startRow(len(record)); err != nil {
+		return err
+	}
+	for i, value := range record {
+		if value == "" {
+			continue
+		}
+		t := TextType
+		if i < len(w.ColumnTypes) {
+			t = w.ColumnTypes[i]
+		}
+		writeCell(w.bw, cellRef(i, w.row), value, t)
+	}
+	return w.endRow()
+}
+
+// WriteValues writes a row of the values. Nil values are empty cells.
+func (w *XLSXRecordWriter) WriteValues(values []interface{}) error {
+	if err := w.startRow(len(values)); err != nil {
+		return err
+	}
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		t := GeneralType
+		if i < len(w.types) {
+			t = w.types[i]
+		}
+		writeCell(w.bw, cellRef(i, w.row), value, t)
+	}
+	return w.endRow()
+}
+
+// startRow checks the limits of the worksheet and writes the beginning of
+// the next row of the cells.
+func (w *XLSXRecordWriter) startRow(cells int) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	if w.row >= xlsxMaxRows {
+		return &XLSXLimitError{"rows", xlsxMaxRows}
+	}
+	if cells > xlsxMaxColumns {
+		return &XLSXLimitError{"columns", xlsxMaxColumns}
+	}
+	w.row++
+	w.bw.WriteString(`<row r="` + strconv.Itoa(w.row) + `">`)
+	return nil
+}
+
+// endRow writes the end of the row, and returns the error of writing the
+// row if any, which bufio.Writer keeps until it is returned.
+func (w *XLSXRecordWriter) endRow() error {
+	_, err := w.bw.WriteString(`</row>`)
+	return err
+}
+
+// Close writes the end of the workbook.
+func (w *XLSXRecordWriter) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	w.bw.WriteString(`</sheetData></worksheet>`)
+	if err := w.bw.Flush(); err != nil {
+		return err
+	}
+	return w.z.Close()
+}
+
+// start writes the beginning of the workbook unless it has been written.
+func (w *XLSXRecordWriter) start() error {
+	if w.z != nil {
+		return nil
+	}
+	w.z = zip.NewWriter(w.w)
+	f, err := createXLSXParts(w.z, w.SheetName)
+	if err != nil {
+		return err
+	}
+	w.bw = bufio.NewWriter(f)
+	w.bw.WriteString(xlsxSheetStart)
+	return nil
+}
+
+// createXLSXParts writes the parts of the workbook except the worksheet, and
+// returns the writer of the worksheet.
+func createXLSXParts(z *zip.Writer, sheetName string) (io.Writer, error) {
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook(sheetName)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	} {
+		f, err := z.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+	return z.Create("xl/worksheets/sheet1.xml")
+}
+
+// xlsxSheetStart is the beginning of the worksheet before the rows.
+const xlsxSheetStart = xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+func writeCell(bw *bufio.Writer, ref string, value interface{}, t ColumnType) {
+	switch t {
+	case TextType:
+		writeStringCell(bw, ref, toString(value), textCellStyle)
+		return
+	case NumberType:
+		if s, ok := value.(string); ok {
+			if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+				if significantDigits(s) > maxSafeDigits {
+					writeStringCell(bw, ref, s, generalCellStyle)
+					return
+				}
+				writeNumberCell(bw, ref, strconv.FormatFloat(f, 'g', -1, 64), generalCellStyle)
+				return
+			}
+		}
+	case DateType:
+		if s, ok := value.(string); ok {
+			if tm, dateOnly, ok := parseDate(s); ok {
+				style := dateTimeCellStyle
+				if dateOnly {
+					style = dateCellStyle
+				}
+				writeNumberCell(bw, ref, excelSerial(tm), style)
+				return
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case float64:
+		writeNumberCell(bw, ref, strconv.FormatFloat(v, 'g', -1, 64), generalCellStyle)
+	case json.Number:
+		if _, err := strconv.ParseFloat(v.String(), 64); err != nil {
+			writeStringCell(bw, ref, v.String(), generalCellStyle)
+			return
+		}
+		writeJSONNumberCell(bw, ref, v.String())
+	case int:
+		writeJSONNumberCell(bw, ref, strconv.Itoa(v))
+	case int8:
+		writeJSONNumberCell(bw, ref, strconv.FormatInt(int64(v), 10))
+	case int16:
+		writeJSONNumberCell(bw, ref, strconv.FormatInt(int64(v), 10))
+	case int32:
+		writeJSONNumberCell(bw, ref, strconv.FormatInt(int64(v), 10))
+	case int64:
+		writeJSONNumberCell(bw, ref, strconv.FormatInt(v, 10))
+	case uint:
+		writeJSONNumberCell(bw, ref, strconv.FormatUint(uint64(v), 10))
+	case uint8:
+		writeJSONNumberCell(bw, ref, strconv.FormatUint(uint64(v), 10))
+	case uint16:
+		writeJSONNumberCell(bw, ref, strconv.FormatUint(uint64(v), 10))
+	case uint32:
+		writeJSONNumberCell(bw, ref, strconv.FormatUint(uint64(v), 10))
+	case uint64:
+		writeJSONNumberCell(bw, ref, strconv.FormatUint(v, 10))
+	case float32:
+		writeNumberCell(bw, ref, strconv.FormatFloat(float64(v), 'g', -1, 32), generalCellStyle)
+	case bool:
+		b := "0"
+		if v {
+			b = "1"
+		}
+		bw.WriteString(`<c r="` + ref + `" t="b"><v>` + b + `</v></c>`)
+	default:
+		writeStringCell(bw, ref, toString(value), generalCellStyle)
+	}
+}
+
+func writeStringCell(bw *bufio.Writer, ref string, s string, style int) {
+	bw.WriteString(`<c r="` + ref + `"` + styleAttr(style) + ` t="inlineStr"><is><t xml:space="preserve">`)
+	xml.EscapeText(bw, []byte(s))
+	bw.WriteString(`</t></is></c>`)
+}
+
+// writeJSONNumberCell writes the number, or text if Excel would round it.
+func writeJSONNumberCell(bw *bufio.Writer, ref string, n string) {
+	if significantDigits(n) > maxSafeDigits {
+		writeStringCell(bw, ref, n, generalCellStyle)
+		return
+	}
+	writeNumberCell(bw, ref, n, generalCellStyle)
+}
+
+// significantDigits returns the number of the significant digits of the
+// number, e.g. 3 for "-0.0123e5" and 1 for "1000".
+func significantDigits(n string) int {
+	if i := strings.IndexAny(n, "eE"); i >= 0 {
+		n = n[:i]
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, n)
+	return len(strings.Trim(digits, "0"))
+}
+
+func writeNumberCell(bw *bufio.Writer, ref string, n string, style int) {
+	bw.WriteString(`<c r="` + ref + `"` + styleAttr(style) + ` t="n"><v>` + n + `</v></c>`)
+}
+
+func styleAttr(style int) string {
+	if style == generalCellStyle {
+		return ""
+	}
+	return ` s="` + strconv.Itoa(style) + `"`
+}
+
+// cellRef returns the cell reference like "A1" of the column (zero-based)
+// and the row (one-based).
+func cellRef(column int, row int) string {
+	name := ""
+	for n := column + 1; n > 0; n = (n - 1) / 26 {
+		name = string(rune('A'+(n-1)%26)) + name
+	}
+	return name + strconv.Itoa(row)
+}
+
+// parseDate parses s in RFC 3339 or "2006-01-02" format.
+func parseDate(s string) (t time.Time, dateOnly bool, ok bool) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, true, true
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, false, true
+	}
+	return time.Time{}, false, false
+}
+
+// excelSerial returns the serial date of t in its own time zone, since
+// Excel dates have no time zone.
+func excelSerial(t time.Time) string {
+	local := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	days := local.Sub(excelEpoch).Hours() / 24
+	return strconv.FormatFloat(days, 'f', -1, 64)
+}
+
+func xlsxWorkbook(sheetName string) string {
+	if sheetName == "" {
+		sheetName = "Sheet1"
+	}
+	name := xmlEscape(sheetName)
+	return xml.Header +
+		`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + name + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+const xlsxContentTypes = xml.Header +
+	`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const xlsxRels = xml.Header +
+	`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbookRels = xml.Header +
+	`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// The order of cellXfs corresponds to the cell style constants.
+const xlsxStyles = xml.Header +
+	`<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy\-mm\-dd"/><numFmt numFmtId="165" formatCode="yyyy\-mm\-dd\ hh:mm:ss"/></numFmts>` +
+	`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="49" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`</styleSheet>`
@@ -0,0 +1,177 @@
+package json2csv_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/yukithm/json2csv/v2"
+)
+
+func TestXLSXColumnTypes(t *testing.T) {
+	obj := []interface{}{
+		map[string]interface{}{"id": "00123", "n": "12.5", "d": "2024-05-01", "x": "abc"},
+	}
+	results, err := json2csv.Flatten(obj, json2csv.FlattenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &bytes.Buffer{}
+	rw := json2csv.NewXLSXRecordWriter(b)
+	rw.NamedColumnTypes = map[string]json2csv.ColumnType{
+		"id": json2csv.TextType,
+		"/n": json2csv.NumberType,
+		"d":  json2csv.DateType,
+		"x":  json2csv.NumberType,
+	}
+	wr := json2csv.NewCSVWriter(ioutil.Discard)
+	wr.HeaderStyle = json2csv.DotNotationStyle
+	if err := wr.WriteRecords(rw, results); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sheet := readZipFile(t, b.Bytes(), "xl/worksheets/sheet1.xml")
+	cells := []string{
+		`<c r="A2" s="2" t="n"><v>45413</v></c>`,
+		`<c r="B2" s="1" t="inlineStr"><is><t xml:space="preserve">00123</t></is></c>`,
+		`<c r="C2" t="n"><v>12.5</v></c>`,
+		`<c r="D2" t="inlineStr"><is><t xml:space="preserve">abc</t></is></c>`,
+	}
+	for i, cell := range cells {
+		if !strings.Contains(sheet, cell) {
+			t.Errorf("%d: Expected %q in %q", i, cell, sheet)
+		}
+	}
+}
+
+func TestXLSXNumberCells(t *testing.T) {
+	results := []json2csv.KeyValue{
+		{"/a": 1, "/b": int64(-2), "/c": uint64(18446744073709551615), "/d": json.Number("1.5e3"), "/e": json.Number("x"), "/f": 2.5, "/g": json.Number("12345678901234567890"), "/h": json.Number("1.23456789012345678e2"), "/i": json.Number("100000000000000000000")},
+	}
+
+	b := &bytes.Buffer{}
+	rw := json2csv.NewXLSXRecordWriter(b)
+	if err := json2csv.NewCSVWriter(ioutil.Discard).WriteRecords(rw, results); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sheet := readZipFile(t, b.Bytes(), "xl/worksheets/sheet1.xml")
+	cells := []string{
+		`<c r="A2" t="n"><v>1</v></c>`,
+		`<c r="B2" t="n"><v>-2</v></c>`,
+		`<c r="C2" t="inlineStr"><is><t xml:space="preserve">18446744073709551615</t></is></c>`,
+		`<c r="D2" t="n"><v>1.5e3</v></c>`,
+		`<c r="E2" t="inlineStr"><is><t xml:space="preserve">x</t></is></c>`,
+		`<c r="F2" t="n"><v>2.5</v></c>`,
+		`<c r="G2" t="inlineStr"><is><t xml:space="preserve">12345678901234567890</t></is></c>`,
+		`<c r="H2" t="inlineStr"><is><t xml:space="preserve">1.23456789012345678e2</t></is></c>`,
+		`<c r="I2" t="n"><v>100000000000000000000</v></c>`,
+	}
+	for i, cell := range cells {
+		if !strings.Contains(sheet, cell) {
+			t.Errorf("%d: Expected %q in %q", i, cell, sheet)
+		}
+	}
+}
+
+func TestXLSXLimits(t *testing.T) {
+	rw := json2csv.NewXLSXRecordWriter(ioutil.Discard)
+	err := rw.WriteRecord(make([]string, 16385))
+	if e, ok := err.(*json2csv.XLSXLimitError); !ok || e.What != "columns" {
+		t.Errorf("Expected XLSXLimitError of columns, but %v", err)
+	}
+	if err := rw.WriteRecord(make([]string, 16384)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < 1048576; i++ {
+		if err := rw.WriteValues(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = rw.WriteValues(nil)
+	if e, ok := err.(*json2csv.XLSXLimitError); !ok || e.What != "rows" {
+		t.Errorf("Expected XLSXLimitError of rows, but %v", err)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestXLSXWriteError(t *testing.T) {
+	rw := json2csv.NewXLSXRecordWriter(failingWriter{})
+	var err error
+	for i := 0; i < 10000 && err == nil; i++ {
+		err = rw.WriteRecord([]string{"abcdefghij"})
+	}
+	if err == nil || err.Error() != "disk full" {
+		t.Errorf("Expected the write error, but %v", err)
+	}
+}
+
+func TestXLSXRecordWriter(t *testing.T) {
+	b := &bytes.Buffer{}
+	rw := json2csv.NewXLSXRecordWriter(b)
+	rw.ColumnTypes = []json2csv.ColumnType{json2csv.TextType, json2csv.NumberType}
+	if err := rw.WriteHeader([]string{"id", "n"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.WriteRecord([]string{"00123", "12.5"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.WriteRecord([]string{"", "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sheet := readZipFile(t, b.Bytes(), "xl/worksheets/sheet1.xml")
+	cells := []string{
+		`<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">id</t></is></c>`,
+		`<c r="A2" s="1" t="inlineStr"><is><t xml:space="preserve">00123</t></is></c><c r="B2" t="n"><v>12.5</v></c></row>`,
+		`<row r="3"><c r="B3" t="inlineStr"><is><t xml:space="preserve">x</t></is></c></row></sheetData></worksheet>`,
+	}
+	for i, cell := range cells {
+		if !strings.Contains(sheet, cell) {
+			t.Errorf("%d: Expected %q in %q", i, cell, sheet)
+		}
+	}
+}
+
+func readZipFile(t *testing.T, data []byte, name string) string {
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range z.File {
+		if f.Name != name {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	t.Fatalf("%s not found", name)
+	return ""
+}